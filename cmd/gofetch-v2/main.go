@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/alirezazahiri/gofetch-v2/internal/config"
@@ -9,11 +10,16 @@ import (
 	"github.com/alirezazahiri/gofetch-v2/internal/jobsservice"
 	"github.com/alirezazahiri/gofetch-v2/internal/repository/postgresql"
 	"github.com/alirezazahiri/gofetch-v2/internal/repository/postgresql/jobsrepo"
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
 )
 
-
 func main() {
 	cfg := config.Load("config.yml")
+	if err := cfg.Jobs.Validate(); err != nil {
+		log.Fatalf("invalid jobs config: %v", err)
+	}
 
 	postgresRepo, err := postgresql.New(&cfg.Repository.Postgres)
 	if err != nil {
@@ -22,8 +28,27 @@ func main() {
 	defer postgresRepo.Close()
 
 	jobsRepo := jobsrepo.New(postgresRepo.DB())
-	jobsService := jobsservice.New(jobsRepo)
-	jobsHandler := jobshandler.New(jobsService)
+	probers, err := pinger.NewDefaultRegistry(&cfg.Prober)
+	if err != nil {
+		log.Fatalf("failed to create probers: %v", err)
+	}
+
+	probeScheduler := scheduler.New(scheduler.Config{
+		Workers:   cfg.Jobs.GlobalConcurrency,
+		MaxQueued: cfg.Jobs.MaxQueuedProbes,
+	})
+	go probeScheduler.Run(context.Background())
+
+	jobsService := jobsservice.New(jobsRepo, probers, hostlimit.New(cfg.HostLimit), probeScheduler, &cfg.Jobs)
+	switch cfg.Jobs.Role {
+	case jobsservice.RoleWorker:
+		jobsService.Dispatch(context.Background())
+		return
+	case jobsservice.RoleAll:
+		go jobsService.Dispatch(context.Background())
+	}
+
+	jobsHandler := jobshandler.New(jobsService, &cfg.HttpServer.Jobs)
 
 	server := httpserver.NewServer(&cfg.HttpServer, &httpserver.Handlers{
 		JobsHandler: jobsHandler,
@@ -2,7 +2,10 @@ package config
 
 import (
 	"github.com/alirezazahiri/gofetch-v2/internal/delivery/httpserver"
+	"github.com/alirezazahiri/gofetch-v2/internal/jobsservice"
 	"github.com/alirezazahiri/gofetch-v2/internal/repository/postgresql"
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
 )
 
 type RepositoryConfig struct {
@@ -11,6 +14,9 @@ type RepositoryConfig struct {
 
 type Config struct {
 	Env        string             `koanf:"env"`
-	HttpServer httpserver.Config   `koanf:"http_server"`
+	HttpServer httpserver.Config  `koanf:"http_server"`
 	Repository RepositoryConfig   `koanf:"repository"`
+	Prober     pinger.Config      `koanf:"prober"`
+	HostLimit  hostlimit.Limits   `koanf:"host_limit"`
+	Jobs       jobsservice.Config `koanf:"jobs"`
 }
@@ -2,12 +2,51 @@ package config
 
 const EnvPrefix = "GOFETCH_V2_"
 
+// defaultEgressDenyCIDRs keeps probes away from loopback, private,
+// carrier-grade nat and link-local (cloud metadata) addresses
+var defaultEgressDenyCIDRs = []string{
+	"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16",
+	"172.16.0.0/12", "192.168.0.0/16", "::/128", "::1/128", "::ffff:0:0/96", "fc00::/7", "fe80::/10",
+}
+
 var defaultConfig = map[string]any{
-	"env": "development",
-	"http_server.port": 8080,
-	"postgresql.host": "localhost",
-	"postgresql.port": 5432,
-	"postgresql.username": "postgres",
-	"postgresql.password": "postgres",
-	"postgresql.dbname": "postgres",
+	"env":                                       "development",
+	"http_server.port":                          8080,
+	"http_server.jobs.max_urls":                 1000,
+	"http_server.jobs.min_timeout_ms":           100,
+	"http_server.jobs.max_timeout_ms":           60000,
+	"http_server.jobs.default_timeout_ms":       5000,
+	"http_server.jobs.max_concurrency":          100,
+	"http_server.jobs.default_concurrency":      10,
+	"http_server.jobs.max_request_bytes":        1 << 20,
+	"http_server.jobs.retry_after_seconds":      5,
+	"postgresql.host":                           "localhost",
+	"postgresql.port":                           5432,
+	"postgresql.username":                       "postgres",
+	"postgresql.password":                       "postgres",
+	"postgresql.dbname":                         "postgres",
+	"prober.cert_expiry_warning_days":           14,
+	"prober.transport.max_idle_conns":           256,
+	"prober.transport.max_idle_conns_per_host":  8,
+	"prober.transport.max_conns_per_host":       32,
+	"prober.transport.idle_conn_timeout_ms":     90000,
+	"prober.transport.dial_timeout_ms":          10000,
+	"prober.transport.tls_handshake_timeout_ms": 10000,
+	"prober.transport.keep_alive_ms":            30000,
+	"prober.transport.disable_keep_alives":      false,
+	"prober.proxy.direct":                       false,
+	"prober.egress.deny_cidrs":                  defaultEgressDenyCIDRs,
+	"prober.egress.allowed_schemes":             []string{"http", "https", "tcp", "tls", "dns"},
+	"host_limit.max_concurrent":                 8,
+	"host_limit.requests_per_second":            0,
+	"host_limit.burst":                          0,
+	"jobs.max_concurrency_per_job":              64,
+	"jobs.global_concurrency":                   512,
+	"jobs.max_queued_probes":                    20000,
+	"jobs.cancel_poll_interval_ms":              2000,
+	"jobs.role":                                 "all",
+	"jobs.lease_ttl_ms":                         30000,
+	"jobs.heartbeat_interval_ms":                10000,
+	"jobs.claim_interval_ms":                    2000,
+	"jobs.claim_batch_size":                     4,
 }
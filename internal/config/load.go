@@ -33,12 +33,12 @@ func Load(configPath string) Config {
 
 	// highest precedence -> overwrite variables with what's inside .env file
 	err = k.Load(confmap.Provider(map[string]any{
-		"repository.postgres.username":             dotenv.Get("POSTGRES_USER"),
-		"repository.postgres.password":             dotenv.Get("POSTGRES_PASSWORD"),
-		"repository.postgres.host":                 dotenv.Get("POSTGRES_HOST"),
-		"repository.postgres.port":                 dotenv.Get("POSTGRES_PORT"),
-		"repository.postgres.dbname":               dotenv.Get("POSTGRES_DB"),
-		"env":                                      dotenv.Get("ENV"),
+		"repository.postgres.username": dotenv.Get("POSTGRES_USER"),
+		"repository.postgres.password": dotenv.Get("POSTGRES_PASSWORD"),
+		"repository.postgres.host":     dotenv.Get("POSTGRES_HOST"),
+		"repository.postgres.port":     dotenv.Get("POSTGRES_PORT"),
+		"repository.postgres.dbname":   dotenv.Get("POSTGRES_DB"),
+		"env":                          dotenv.Get("ENV"),
 	}, "."), nil)
 
 	if err != nil {
@@ -0,0 +1,11 @@
+package jobsdto
+
+type CancelRequest struct {
+	ID string `json:"id"`
+}
+
+type CancelResponse struct {
+	JobId           string `json:"job_id"`
+	Status          string `json:"status"`
+	CancelRequested bool   `json:"cancel_requested"`
+}
@@ -2,15 +2,94 @@ package jobsdto
 
 import "time"
 
+type JSONPathAssertion struct {
+	Path   string `json:"path" binding:"required,json_path"`
+	Equals any    `json:"equals"`
+}
+
+type AssertionsSpec struct {
+	BodyContains    []string            `json:"body_contains"`
+	BodyNotContains []string            `json:"body_not_contains"`
+	BodyMatches     []string            `json:"body_matches" binding:"dive,regexp"`
+	JSONPath        []JSONPathAssertion `json:"json_path" binding:"dive"`
+	MaxBodyBytes    int64               `json:"max_body_bytes" binding:"min=0"`
+}
+
+type TcpSpec struct {
+	Send        string `json:"send"`
+	Expect      string `json:"expect"`
+	ExpectRegex string `json:"expect_regex" binding:"omitempty,regexp"`
+}
+
+type TlsSpec struct {
+	ExpiryWarningDays int    `json:"expiry_warning_days" binding:"min=0"`
+	Profile           string `json:"profile"`
+}
+
+type DnsSpec struct {
+	RecordType string   `json:"record_type" binding:"omitempty,dns_record_type"`
+	Resolver   string   `json:"resolver"`
+	Expected   []string `json:"expected"`
+}
+
+type RedirectSpec struct {
+	Mode    string `json:"mode" binding:"omitempty,oneof=follow none same_host"`
+	MaxHops int    `json:"max_hops" binding:"min=0"`
+}
+
+type RetrySpec struct {
+	MaxAttempts   int      `json:"max_attempts" binding:"min=0,max=10"`
+	BackoffBaseMs int      `json:"backoff_base_ms" binding:"min=0"`
+	BackoffCapMs  int      `json:"backoff_cap_ms" binding:"min=0"`
+	Jitter        float64  `json:"jitter" binding:"min=0,max=1"`
+	RetryOn       []string `json:"retry_on" binding:"dive,error_class"`
+	RetryStatus   []string `json:"retry_status" binding:"dive,status_range"`
+}
+
+type ProxySpec struct {
+	HttpProxy  string   `json:"http_proxy" binding:"omitempty,proxy_url"`
+	HttpsProxy string   `json:"https_proxy" binding:"omitempty,proxy_url"`
+	NoProxy    []string `json:"no_proxy"`
+	Direct     bool     `json:"direct"`
+}
+
+type ProbeSpec struct {
+	Method         string            `json:"method" binding:"omitempty,http_method"`
+	Headers        map[string]string `json:"headers"`
+	Body           string            `json:"body"`
+	ExpectedStatus []string          `json:"expected_status" binding:"dive,status_range"`
+	Assertions     *AssertionsSpec   `json:"assertions"`
+	Tcp            *TcpSpec          `json:"tcp"`
+	Tls            *TlsSpec          `json:"tls"`
+	Dns            *DnsSpec          `json:"dns"`
+	Redirect       *RedirectSpec     `json:"redirect"`
+	Retry          *RetrySpec        `json:"retry"`
+	Proxy          *ProxySpec        `json:"proxy"`
+}
+
+type HostLimitSpec struct {
+	MaxConcurrent     int     `json:"max_concurrent" binding:"min=0"`
+	RequestsPerSecond float64 `json:"requests_per_second" binding:"min=0"`
+	Burst             int     `json:"burst" binding:"min=0"`
+}
+
 type CheckRequest struct {
-	Urls        []string `json:"urls"`
-	Concurrency int      `json:"concurrency"`
-	TimeoutMs   int      `json:"timeout_ms"`
+	Urls        []string             `json:"urls" binding:"required,min=1,dive,required"`
+	Concurrency int                  `json:"concurrency" binding:"min=1"`
+	Weight      int                  `json:"weight" binding:"min=0,max=100"`
+	Adaptive    bool                 `json:"adaptive"`
+	TimeoutMs   int                  `json:"timeout_ms" binding:"min=1"`
+	Probe       *ProbeSpec           `json:"probe"`
+	UrlProbes   map[string]ProbeSpec `json:"url_probes" binding:"dive"`
+	HostLimit   *HostLimitSpec       `json:"host_limit"`
+	Normalize   bool                 `json:"normalize"`
+	Deduplicate bool                 `json:"deduplicate"`
 }
 
 type CheckResponse struct {
-	JobId     string    `json:"job_id"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	JobId       string    `json:"job_id"`
+	Status      string    `json:"status"`
+	Concurrency int       `json:"concurrency"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -6,16 +6,97 @@ type RetrieveRequest struct {
 	ID string `json:"id"`
 }
 
+type TimingsItem struct {
+	DnsMs      float64 `json:"dns_ms"`
+	ConnectMs  float64 `json:"connect_ms"`
+	TlsMs      float64 `json:"tls_ms"`
+	TtfbMs     float64 `json:"ttfb_ms"`
+	TransferMs float64 `json:"transfer_ms"`
+}
+
+type RedirectItem struct {
+	URL        string  `json:"url"`
+	StatusCode int     `json:"status_code"`
+	LatencyMs  float64 `json:"latency_ms"`
+}
+
+type AttemptItem struct {
+	Attempt    int     `json:"attempt"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	LatencyMs  float64 `json:"latency_ms"`
+	BackoffMs  float64 `json:"backoff_ms,omitempty"`
+}
+
+type TcpItem struct {
+	RemoteAddr string `json:"remote_addr"`
+	Banner     string `json:"banner,omitempty"`
+}
+
+type CertificateItem struct {
+	Subject         string    `json:"subject"`
+	Issuer          string    `json:"issuer"`
+	DNSNames        []string  `json:"dns_names,omitempty"`
+	NotBefore       time.Time `json:"not_before"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+}
+
+type TlsItem struct {
+	Version       string            `json:"version"`
+	ServerName    string            `json:"server_name,omitempty"`
+	Chain         []CertificateItem `json:"chain"`
+	HostnameValid bool              `json:"hostname_valid"`
+	Verified      bool              `json:"verified"`
+	VerifyError   string            `json:"verify_error,omitempty"`
+	ExpiresSoon   bool              `json:"expires_soon"`
+}
+
+type DnsItem struct {
+	Resolver   string   `json:"resolver"`
+	RecordType string   `json:"record_type"`
+	RCode      string   `json:"rcode,omitempty"`
+	Answers    []string `json:"answers,omitempty"`
+}
+
 type JobResultItem struct {
-	URL       string `json:"url"`
-	LatencyMs int64  `json:"latency_ms"`
-	Status    string `json:"status"`
+	URL               string         `json:"url"`
+	NormalizedURL     string         `json:"normalized_url,omitempty"`
+	Scheme            string         `json:"scheme,omitempty"`
+	LatencyMs         int64          `json:"latency_ms"`
+	Status            string         `json:"status"`
+	StatusCode        int            `json:"status_code,omitempty"`
+	Proto             string         `json:"proto,omitempty"`
+	BytesRead         int64          `json:"bytes_read"`
+	FinalURL          string         `json:"final_url,omitempty"`
+	Redirects         []RedirectItem `json:"redirects,omitempty"`
+	HttpsDowngrade    bool           `json:"https_downgrade,omitempty"`
+	Proxy             string         `json:"proxy,omitempty"`
+	Error             string         `json:"error,omitempty"`
+	Timings           TimingsItem    `json:"timings"`
+	AttemptCount      int            `json:"attempt_count"`
+	Attempts          []AttemptItem  `json:"attempts,omitempty"`
+	AssertionFailures []string       `json:"assertion_failures,omitempty"`
+	Tcp               *TcpItem       `json:"tcp,omitempty"`
+	Tls               *TlsItem       `json:"tls,omitempty"`
+	Dns               *DnsItem       `json:"dns,omitempty"`
+}
+
+type ConcurrencyLimitItem struct {
+	Host  string `json:"host,omitempty"`
+	Limit int    `json:"limit"`
+	AtMs  int64  `json:"at_ms"`
+}
+
+type JobMetadataItem struct {
+	ConcurrencyLimits []ConcurrencyLimitItem `json:"concurrency_limits,omitempty"`
 }
 
 type RetrieveResponse struct {
-	JobID      string          `json:"job_id"`
-	Results    []JobResultItem `json:"results"`
-	Status     string          `json:"status"`
-	DurationMs int64           `json:"duration_ms"`
-	CreatedAt  time.Time       `json:"created_at"`
+	JobID      string           `json:"job_id"`
+	Results    []JobResultItem  `json:"results"`
+	Status     string           `json:"status"`
+	DurationMs int64            `json:"duration_ms"`
+	Metadata   *JobMetadataItem `json:"metadata,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
 }
@@ -1,5 +1,8 @@
 package httpserver
 
+import "github.com/alirezazahiri/gofetch-v2/internal/delivery/httpserver/jobshandler"
+
 type Config struct {
-	Port uint `koanf:"port"`
+	Port uint               `koanf:"port"`
+	Jobs jobshandler.Config `koanf:"jobs"`
 }
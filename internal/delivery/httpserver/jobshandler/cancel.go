@@ -0,0 +1,32 @@
+package jobshandler
+
+import (
+	"errors"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/internal/jobsservice"
+	"github.com/alirezazahiri/gofetch-v2/pkg/envelope"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CancelJob(c *gin.Context) {
+	request := jobsdto.CancelRequest{
+		ID: c.Param("id"),
+	}
+
+	response, err := h.svc.Cancel(c.Request.Context(), &request)
+
+	switch {
+	case errors.Is(err, jobsservice.ErrJobNotFound):
+		envelope.NotFound(c, "Job not found")
+		return
+	case errors.Is(err, jobsservice.ErrJobFinished):
+		envelope.Conflict(c, "Job already finished", nil)
+		return
+	case err != nil:
+		envelope.InternalServerError(c, "Failed to cancel job", err.Error())
+		return
+	}
+
+	envelope.Accepted(c, response)
+}
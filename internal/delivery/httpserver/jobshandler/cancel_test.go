@@ -0,0 +1,97 @@
+package jobshandler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/alirezazahiri/gofetch-v2/internal/jobsservice"
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// cancelRepo holds just enough jobs to cancel, the methods the cancel
+// endpoint does not use are left to the embedded nil interface
+type cancelRepo struct {
+	jobsservice.Repository
+	jobs map[string]*entity.Job
+	err  error
+}
+
+func (r *cancelRepo) GetJob(id string) (*entity.Job, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return job, nil
+}
+
+func (r *cancelRepo) RequestJobCancel(id string) (bool, error) {
+	job := r.jobs[id]
+	if job.IsFinished() {
+		return false, nil
+	}
+	job.CancelRequested = true
+	return true, nil
+}
+
+func newCancelRouter(t *testing.T, repo *cancelRepo) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	probers, err := pinger.NewDefaultRegistry(&pinger.Config{})
+	require.NoError(t, err)
+	svc := jobsservice.New(repo, probers, hostlimit.New(hostlimit.Limits{}), scheduler.New(scheduler.Config{}), &jobsservice.Config{Role: jobsservice.RoleAPI})
+
+	router := gin.New()
+	New(svc, &Config{}).RegisterRoutes(router.Group("/jobs"))
+	return router
+}
+
+func cancelJob(router *gin.Engine, id string) (*httptest.ResponseRecorder, map[string]any) {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/jobs/"+id+"/cancel", nil))
+
+	var body map[string]any
+	_ = json.Unmarshal(recorder.Body.Bytes(), &body)
+	return recorder, body
+}
+
+func TestCancelJob(t *testing.T) {
+	repo := &cancelRepo{jobs: map[string]*entity.Job{
+		"running": {ID: "running", Status: entity.JobStatusRunning},
+		"done":    {ID: "done", Status: entity.JobStatusCompleted},
+	}}
+	router := newCancelRouter(t, repo)
+
+	recorder, body := cancelJob(router, "running")
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Equal(t, map[string]any{"job_id": "running", "status": "running", "cancel_requested": true}, body["data"])
+	assert.True(t, repo.jobs["running"].CancelRequested)
+
+	recorder, body = cancelJob(router, "done")
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+	assert.Equal(t, "CONFLICT", body["error"].(map[string]any)["code"])
+
+	recorder, body = cancelJob(router, "missing")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "NOT_FOUND", body["error"].(map[string]any)["code"])
+}
+
+func TestCancelJobReportsStorageErrors(t *testing.T) {
+	router := newCancelRouter(t, &cancelRepo{err: errors.New("connection refused")})
+
+	recorder, _ := cancelJob(router, "running")
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
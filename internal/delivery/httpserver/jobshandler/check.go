@@ -1,35 +1,60 @@
 package jobshandler
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
 	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
 	"github.com/alirezazahiri/gofetch-v2/pkg/envelope"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
 	"github.com/gin-gonic/gin"
 )
 
 func (h *Handler) Check(c *gin.Context) {
 	var request jobsdto.CheckRequest
 
-	if err := c.ShouldBindJSON(&request); err != nil {
+	if h.cfg.MaxRequestBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.MaxRequestBytes)
+	}
+
+	if err := json.NewDecoder(c.Request.Body).Decode(&request); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			envelope.PayloadTooLarge(c, "Request body too large", map[string]int64{
+				"max_request_bytes": tooLarge.Limit,
+			})
+			return
+		}
 		envelope.BadRequest(c, "Invalid request format", err.Error())
 		return
 	}
 
-	if len(request.Urls) == 0 {
-		envelope.ValidationError(c, "Validation failed", map[string]string{
-			"urls": "must not be empty",
-		})
+	applyDefaults(&request, h.cfg)
+
+	details := map[string]string{}
+	if err := validationDetails(h.validate.Struct(&request), details); err != nil {
+		envelope.InternalServerError(c, "Failed to validate request", err.Error())
 		return
 	}
+	validateTargets(details, &request, h.svc)
+	validateProbes(details, &request, h.svc)
 
-	if request.Concurrency <= 0 {
-		envelope.ValidationError(c, "Validation failed", map[string]string{
-			"concurrency": "must be greater than 0",
-		})
+	if len(details) > 0 {
+		envelope.ValidationError(c, "Validation failed", details)
 		return
 	}
 
 	job, err := h.svc.Check(c.Request.Context(), &request)
 
+	if errors.Is(err, scheduler.ErrQueueFull) {
+		if h.cfg.RetryAfterSeconds > 0 {
+			c.Header("Retry-After", strconv.Itoa(h.cfg.RetryAfterSeconds))
+		}
+		envelope.TooManyRequests(c, "Too many probes queued, try again later", nil)
+		return
+	}
 	if err != nil {
 		envelope.InternalServerError(c, "Failed to create job", err.Error())
 		return
@@ -0,0 +1,232 @@
+package jobshandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/alirezazahiri/gofetch-v2/internal/jobsservice"
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkRepo keeps the jobs an api only instance creates, the methods the
+// check endpoint does not use are left to the embedded nil interface
+type checkRepo struct {
+	jobsservice.Repository
+	jobs []*entity.Job
+}
+
+func (r *checkRepo) CreateJob(job *entity.Job) error {
+	r.jobs = append(r.jobs, job)
+	return nil
+}
+
+var testCheckConfig = Config{
+	MaxURLs:            3,
+	MinTimeoutMs:       100,
+	MaxTimeoutMs:       10000,
+	DefaultTimeoutMs:   5000,
+	MaxConcurrency:     8,
+	DefaultConcurrency: 2,
+	MaxRequestBytes:    1024,
+}
+
+func newCheckRouter(t *testing.T, repo *checkRepo, cfg Config) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	probers, err := pinger.NewDefaultRegistry(&pinger.Config{
+		Egress: pinger.EgressConfig{DenyCIDRs: []string{"10.0.0.0/8"}},
+	})
+	require.NoError(t, err)
+	svc := jobsservice.New(repo, probers, hostlimit.New(hostlimit.Limits{}), scheduler.New(scheduler.Config{}), &jobsservice.Config{
+		Role:                 jobsservice.RoleAPI,
+		MaxConcurrencyPerJob: 8,
+	})
+
+	router := gin.New()
+	New(svc, &cfg).RegisterRoutes(router.Group("/jobs"))
+	return router
+}
+
+func postCheck(router *gin.Engine, body string) (*httptest.ResponseRecorder, map[string]any) {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/jobs/check", strings.NewReader(body)))
+
+	var response map[string]any
+	_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder, response
+}
+
+func errorOf(response map[string]any) map[string]any {
+	errorBody, _ := response["error"].(map[string]any)
+	return errorBody
+}
+
+func TestCheckAppliesDefaults(t *testing.T) {
+	repo := &checkRepo{}
+	router := newCheckRouter(t, repo, testCheckConfig)
+
+	recorder, response := postCheck(router, `{"urls": ["https://example.com"]}`)
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+	assert.Equal(t, float64(2), response["data"].(map[string]any)["concurrency"])
+
+	require.Len(t, repo.jobs, 1)
+	var stored jobsdto.CheckRequest
+	require.NoError(t, json.Unmarshal(repo.jobs[0].Request, &stored))
+	assert.Equal(t, 2, stored.Concurrency)
+	assert.Equal(t, 5000, stored.TimeoutMs)
+}
+
+func TestCheckReportsInvalidUrlsByIndex(t *testing.T) {
+	repo := &checkRepo{}
+	cfg := testCheckConfig
+	cfg.MaxURLs = 0
+	router := newCheckRouter(t, repo, cfg)
+
+	recorder, response := postCheck(router, `{"urls": [
+		"https://ok.example/a",
+		"ftp://files.example",
+		"http://",
+		" https://padded.example",
+		"example.com",
+		"http://[::1",
+		"http://10.0.0.1/admin",
+		"",
+		"https://ok.example/b"
+	]}`)
+
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, "VALIDATION_ERROR", errorOf(response)["code"])
+	assert.Equal(t, map[string]any{
+		"urls[1]": `unsupported scheme "ftp"`,
+		"urls[2]": "missing host",
+		"urls[3]": "url must not be empty or padded with whitespace",
+		"urls[4]": "missing scheme",
+		"urls[5]": "missing ']' in host",
+		"urls[6]": "blocked by egress policy: address 10.0.0.1 is not allowed",
+		"urls[7]": "is required",
+	}, errorOf(response)["details"])
+	assert.Empty(t, repo.jobs)
+}
+
+func TestCheckReportsInvalidUrlsByIndexOnceNormalized(t *testing.T) {
+	router := newCheckRouter(t, &checkRepo{}, testCheckConfig)
+
+	recorder, response := postCheck(router, `{
+		"urls": ["Example.COM/health", "http://exa mple.com", "http://"],
+		"normalize": true
+	}`)
+
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, map[string]any{
+		"urls[1]": `invalid character " " in host name`,
+		"urls[2]": "missing host",
+	}, errorOf(response)["details"])
+}
+
+func TestCheckReportsEveryInvalidField(t *testing.T) {
+	repo := &checkRepo{}
+	router := newCheckRouter(t, repo, testCheckConfig)
+
+	recorder, response := postCheck(router, `{
+		"urls": ["https://a.example", "https://b.example", "https://c.example", "https://d.example"],
+		"concurrency": 9,
+		"timeout_ms": 50,
+		"weight": 101,
+		"probe": {
+			"method": "BREW",
+			"expected_status": ["2xx", "abc"],
+			"assertions": {"body_matches": ["("], "json_path": [{"path": "data"}]},
+			"dns": {"record_type": "SRV"},
+			"retry": {"backoff_base_ms": 500, "backoff_cap_ms": 100, "retry_on": ["weather"]},
+			"proxy": {"http_proxy": "ftp://proxy.example"}
+		},
+		"host_limit": {"max_concurrent": -1}
+	}`)
+
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, "VALIDATION_ERROR", errorOf(response)["code"])
+	assert.Equal(t, map[string]any{
+		"urls":                               "must contain at most 3 items",
+		"concurrency":                        "must be at most 8",
+		"timeout_ms":                         "must be at least 100",
+		"weight":                             "must be at most 100",
+		"probe.method":                       "unsupported http method",
+		"probe.expected_status[1]":           "must be a status code, range or class such as 204, 200-299 or 2xx",
+		"probe.assertions.body_matches[0]":   "must be a valid regular expression",
+		"probe.assertions.json_path[0].path": "must be a json path such as $.data.items[0].id",
+		"probe.dns.record_type":              "must be one of A, AAAA, CNAME, MX, TXT",
+		"probe.retry.backoff_cap_ms":         "must not be less than backoff_base_ms",
+		"probe.retry.retry_on[0]":            "unknown error class",
+		"probe.proxy.http_proxy":             "must be an http, https or socks5 url with a host",
+		"host_limit.max_concurrent":          "must be at least 0",
+	}, errorOf(response)["details"])
+	assert.Empty(t, repo.jobs)
+}
+
+func TestCheckRejectsProbesTheTagsCannotCheck(t *testing.T) {
+	router := newCheckRouter(t, &checkRepo{}, testCheckConfig)
+
+	recorder, response := postCheck(router, `{
+		"urls": ["https://a.example"],
+		"probe": {"tls": {"profile": "missing"}},
+		"url_probes": {
+			"https://a.example": {"dns": {"resolver": "10.0.0.53"}},
+			"https://other.example": {}
+		}
+	}`)
+
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, map[string]any{
+		"probe.tls.profile":                          "unknown tls profile",
+		"url_probes[https://a.example].dns.resolver": "blocked by egress policy: address 10.0.0.53 is not allowed",
+		"url_probes[https://other.example]":          "url is not part of urls",
+	}, errorOf(response)["details"])
+}
+
+func TestCheckRejectsTimeoutAboveLimit(t *testing.T) {
+	router := newCheckRouter(t, &checkRepo{}, testCheckConfig)
+
+	recorder, response := postCheck(router, `{"urls": ["https://example.com"], "timeout_ms": 20000}`)
+
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, map[string]any{"timeout_ms": "must be at most 10000"}, errorOf(response)["details"])
+}
+
+func TestCheckRejectsMissingUrls(t *testing.T) {
+	router := newCheckRouter(t, &checkRepo{}, testCheckConfig)
+
+	recorder, response := postCheck(router, `{"concurrency": 1}`)
+
+	require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, map[string]any{"urls": "is required"}, errorOf(response)["details"])
+}
+
+func TestCheckRejectsOversizedBody(t *testing.T) {
+	router := newCheckRouter(t, &checkRepo{}, testCheckConfig)
+
+	recorder, response := postCheck(router, `{"urls": ["https://example.com/`+strings.Repeat("a", 2048)+`"]}`)
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Equal(t, "PAYLOAD_TOO_LARGE", errorOf(response)["code"])
+	assert.Equal(t, map[string]any{"max_request_bytes": float64(1024)}, errorOf(response)["details"])
+}
+
+func TestCheckRejectsMalformedJSON(t *testing.T) {
+	router := newCheckRouter(t, &checkRepo{}, testCheckConfig)
+
+	recorder, response := postCheck(router, `{"urls": [`)
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "BAD_REQUEST", errorOf(response)["code"])
+}
@@ -0,0 +1,16 @@
+package jobshandler
+
+// Config bounds what a single check request may ask for. Timeouts and
+// concurrency left out of a request fall back to the defaults.
+// RetryAfterSeconds is sent back when the scheduler turns a job away, which
+// only happens on instances that run jobs themselves
+type Config struct {
+	MaxURLs            int   `koanf:"max_urls"`
+	MinTimeoutMs       int   `koanf:"min_timeout_ms"`
+	MaxTimeoutMs       int   `koanf:"max_timeout_ms"`
+	DefaultTimeoutMs   int   `koanf:"default_timeout_ms"`
+	MaxConcurrency     int   `koanf:"max_concurrency"`
+	DefaultConcurrency int   `koanf:"default_concurrency"`
+	MaxRequestBytes    int64 `koanf:"max_request_bytes"`
+	RetryAfterSeconds  int   `koanf:"retry_after_seconds"`
+}
@@ -3,19 +3,25 @@ package jobshandler
 import (
 	"github.com/alirezazahiri/gofetch-v2/internal/jobsservice"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 )
 
 type Handler struct {
-	svc *jobsservice.Service
+	svc      *jobsservice.Service
+	cfg      *Config
+	validate *validator.Validate
 }
 
-func New(svc *jobsservice.Service) *Handler {
+func New(svc *jobsservice.Service, cfg *Config) *Handler {
 	return &Handler{
-		svc: svc,
+		svc:      svc,
+		cfg:      cfg,
+		validate: newValidator(cfg),
 	}
 }
 
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/check", h.Check)
 	router.GET("/:id", h.RetrieveJob)
-}
\ No newline at end of file
+	router.POST("/:id/cancel", h.CancelJob)
+}
@@ -12,7 +12,7 @@ func (h *Handler) RetrieveJob(c *gin.Context) {
 	}
 
 	response, err := h.svc.Retrieve(c.Request.Context(), &request)
-	
+
 	if err != nil {
 		envelope.InternalServerError(c, "Failed to retrieve job", err.Error())
 		return
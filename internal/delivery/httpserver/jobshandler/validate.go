@@ -0,0 +1,223 @@
+package jobshandler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/internal/jobsservice"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/alirezazahiri/gofetch-v2/pkg/urlnorm"
+	"github.com/go-playground/validator/v10"
+)
+
+// newValidator builds the validator of check requests. It reads the binding
+// tags of the dtos, names fields after their json keys and enforces the
+// limits of cfg on the request as a whole
+func newValidator(cfg *Config) *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	stringRule := func(valid func(string) bool) validator.Func {
+		return func(fl validator.FieldLevel) bool {
+			return valid(fl.Field().String())
+		}
+	}
+	noError := func(parse func(string) error) func(string) bool {
+		return func(value string) bool { return parse(value) == nil }
+	}
+
+	v.RegisterValidation("http_method", stringRule(pinger.IsSupportedMethod))
+	v.RegisterValidation("dns_record_type", stringRule(pinger.IsSupportedDNSRecordType))
+	v.RegisterValidation("error_class", stringRule(pinger.IsErrorClass))
+	v.RegisterValidation("status_range", stringRule(noError(func(value string) error {
+		_, err := pinger.ParseStatusRange(value)
+		return err
+	})))
+	v.RegisterValidation("json_path", stringRule(noError(func(value string) error {
+		_, err := pinger.ParseJSONPath(value)
+		return err
+	})))
+	v.RegisterValidation("regexp", stringRule(noError(func(value string) error {
+		_, err := regexp.Compile(value)
+		return err
+	})))
+	v.RegisterValidation("proxy_url", stringRule(noError(func(value string) error {
+		_, err := pinger.ParseProxyURL(value)
+		return err
+	})))
+
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		request := sl.Current().Interface().(jobsdto.CheckRequest)
+		if cfg.MaxURLs > 0 && len(request.Urls) > cfg.MaxURLs {
+			sl.ReportError(request.Urls, "urls", "Urls", "max", fmt.Sprint(cfg.MaxURLs))
+		}
+		if cfg.MaxConcurrency > 0 && request.Concurrency > cfg.MaxConcurrency {
+			sl.ReportError(request.Concurrency, "concurrency", "Concurrency", "max", fmt.Sprint(cfg.MaxConcurrency))
+		}
+		if request.TimeoutMs > 0 && request.TimeoutMs < cfg.MinTimeoutMs {
+			sl.ReportError(request.TimeoutMs, "timeout_ms", "TimeoutMs", "min", fmt.Sprint(cfg.MinTimeoutMs))
+		}
+		if cfg.MaxTimeoutMs > 0 && request.TimeoutMs > cfg.MaxTimeoutMs {
+			sl.ReportError(request.TimeoutMs, "timeout_ms", "TimeoutMs", "max", fmt.Sprint(cfg.MaxTimeoutMs))
+		}
+	}, jobsdto.CheckRequest{})
+
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		retry := sl.Current().Interface().(jobsdto.RetrySpec)
+		if retry.BackoffCapMs > 0 && retry.BackoffCapMs < retry.BackoffBaseMs {
+			sl.ReportError(retry.BackoffCapMs, "backoff_cap_ms", "BackoffCapMs", "backoff_cap", "")
+		}
+	}, jobsdto.RetrySpec{})
+
+	return v
+}
+
+// applyDefaults fills in the fields a request may leave out
+func applyDefaults(request *jobsdto.CheckRequest, cfg *Config) {
+	if request.Concurrency == 0 {
+		request.Concurrency = cfg.DefaultConcurrency
+	}
+	if request.TimeoutMs == 0 {
+		request.TimeoutMs = cfg.DefaultTimeoutMs
+	}
+}
+
+// validationDetails turns the errors of the validator into a map of field path to message
+func validationDetails(err error, details map[string]string) error {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	for _, fe := range fieldErrors {
+		// drop the name of the validated struct, "CheckRequest.probe.method" becomes "probe.method"
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		details[field] = validationMessage(fe)
+	}
+	return nil
+}
+
+func validationMessage(fe validator.FieldError) string {
+	kind := fe.Kind()
+	collection := kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if collection {
+			return fmt.Sprintf("must contain at least %s items", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if collection {
+			return fmt.Sprintf("must contain at most %s items", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "http_method":
+		return "unsupported http method"
+	case "dns_record_type":
+		return "must be one of A, AAAA, CNAME, MX, TXT"
+	case "error_class":
+		return "unknown error class"
+	case "status_range":
+		return "must be a status code, range or class such as 204, 200-299 or 2xx"
+	case "json_path":
+		return "must be a json path such as $.data.items[0].id"
+	case "regexp":
+		return "must be a valid regular expression"
+	case "proxy_url":
+		return "must be an http, https or socks5 url with a host"
+	case "backoff_cap":
+		return "must not be less than backoff_base_ms"
+	}
+
+	return "failed the " + fe.Tag() + " check"
+}
+
+// validateTargets parses every url strictly, in its normalized form when the
+// request asks for normalization, and rejects urls the egress policy refuses
+// up front. Names resolving to a denied address are only caught when probed
+// and recorded as blocked
+func validateTargets(details map[string]string, request *jobsdto.CheckRequest, svc *jobsservice.Service) {
+	for i, raw := range request.Urls {
+		field := fmt.Sprintf("urls[%d]", i)
+		if _, ok := details[field]; ok || raw == "" {
+			continue
+		}
+
+		target := raw
+		if request.Normalize {
+			normalized, err := urlnorm.Normalize(raw, urlnorm.DefaultScheme)
+			if err != nil {
+				details[field] = err.Error()
+				continue
+			}
+			target = normalized
+		}
+
+		parsed, err := urlnorm.Parse(target)
+		if err != nil {
+			details[field] = err.Error()
+			continue
+		}
+		if !svc.SupportsScheme(parsed.Scheme) {
+			details[field] = fmt.Sprintf("unsupported scheme %q", parsed.Scheme)
+			continue
+		}
+		if err := svc.CheckTarget(target); err != nil {
+			details[field] = err.Error()
+		}
+	}
+}
+
+// validateProbes checks what the struct tags cannot: that every per-url
+// override belongs to a submitted url, that TLS profiles exist and that
+// requested resolvers pass the egress policy
+func validateProbes(details map[string]string, request *jobsdto.CheckRequest, svc *jobsservice.Service) {
+	validateTLSProfile(details, "probe", request.Probe, svc.HasTLSProfile)
+	validateResolver(details, "probe", request.Probe, svc.CheckResolver)
+
+	for url, probe := range request.UrlProbes {
+		field := fmt.Sprintf("url_probes[%s]", url)
+		if !slices.Contains(request.Urls, url) {
+			details[field] = "url is not part of urls"
+			continue
+		}
+		validateTLSProfile(details, field, &probe, svc.HasTLSProfile)
+		validateResolver(details, field, &probe, svc.CheckResolver)
+	}
+}
+
+func validateTLSProfile(details map[string]string, field string, probe *jobsdto.ProbeSpec, hasTLSProfile func(string) bool) {
+	if probe == nil || probe.Tls == nil || probe.Tls.Profile == "" {
+		return
+	}
+	if !hasTLSProfile(probe.Tls.Profile) {
+		details[field+".tls.profile"] = "unknown tls profile"
+	}
+}
+
+func validateResolver(details map[string]string, field string, probe *jobsdto.ProbeSpec, checkResolver func(string) error) {
+	if probe == nil || probe.Dns == nil || probe.Dns.Resolver == "" {
+		return
+	}
+	if err := checkResolver(probe.Dns.Resolver); err != nil {
+		details[field+".dns.resolver"] = err.Error()
+	}
+}
@@ -33,7 +33,7 @@ func (s *Server) Start() {
 
 	router.GET("/health", Healthcheck)
 
-	// jobs api routes 
+	// jobs api routes
 	jobsRouter := router.Group("/jobs")
 	s.handlers.JobsHandler.RegisterRoutes(jobsRouter)
 
@@ -1,6 +1,9 @@
 package entity
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type JobStatus uint8
 
@@ -9,15 +12,49 @@ const (
 	JobStatusRunning
 	JobStatusCompleted
 	JobStatusFailed
+	JobStatusCancelled
 )
 
+// ConcurrencyLimit is a limit an adaptive job moved to, AtMs after it started.
+// Host is empty for the limit shared by every host of the job
+type ConcurrencyLimit struct {
+	Host  string `json:"host,omitempty"`
+	Limit int    `json:"limit"`
+	AtMs  int64  `json:"at_ms"`
+}
+
+// JobMetadata is what a job records about how it ran
+type JobMetadata struct {
+	ConcurrencyLimits []ConcurrencyLimit `json:"concurrency_limits,omitempty"`
+}
+
 type Job struct {
-	ID         string      `gorm:"primaryKey"`
-	Status     JobStatus   `gorm:"not null;default:0"`
-	DurationMs int64       `gorm:"not null"`
-	CreatedAt  time.Time   `gorm:"not null"`
-	UpdatedAt  time.Time   `gorm:"not null"`
-	JobResults []JobResult `gorm:"foreignKey:JobID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ID     string    `gorm:"primaryKey"`
+	Status JobStatus `gorm:"not null;default:0"`
+	// CancelRequested is set by whichever instance got the cancel request,
+	// the instance running the job picks it up and stops
+	CancelRequested bool `gorm:"not null;default:false"`
+	// Request is the check request the job was submitted with, kept so the
+	// job can be resumed by another process
+	Request json.RawMessage `gorm:"type:jsonb;serializer:json"`
+	// LeaseOwner is the instance running the job until LeaseExpiresAt,
+	// after which any worker may claim the job
+	LeaseOwner     string       `gorm:"not null;default:'';index"`
+	LeaseExpiresAt *time.Time   `gorm:"index"`
+	DurationMs     int64        `gorm:"not null"`
+	Metadata       *JobMetadata `gorm:"type:jsonb;serializer:json"`
+	CreatedAt      time.Time    `gorm:"not null"`
+	UpdatedAt      time.Time    `gorm:"not null"`
+	JobResults     []JobResult  `gorm:"foreignKey:JobID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// IsFinished reports whether the job reached a state it never leaves
+func (j *Job) IsFinished() bool {
+	switch j.Status {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		return true
+	}
+	return false
 }
 
 type JobResultStatus uint8
@@ -26,15 +63,100 @@ const (
 	JobResultStatusCompleted JobResultStatus = iota
 	JobResultStatusFailed
 	JobResultStatusTimeout
+	JobResultStatusRefused
+	JobResultStatusReset
+	JobResultStatusDegraded
+	JobResultStatusNXDomain
+	JobResultStatusServFail
+	JobResultStatusMismatch
+	JobResultStatusBlocked
+	JobResultStatusCancelled
 )
 
+// PhaseTimings holds the duration of each phase of a probe in milliseconds
+type PhaseTimings struct {
+	DnsMs      float64 `gorm:"not null;default:0"`
+	ConnectMs  float64 `gorm:"not null;default:0"`
+	TlsMs      float64 `gorm:"not null;default:0"`
+	TtfbMs     float64 `gorm:"not null;default:0"`
+	TransferMs float64 `gorm:"not null;default:0"`
+}
+
+// TcpDetails is what a tcp probe observed on the connection
+type TcpDetails struct {
+	RemoteAddr string `json:"remote_addr"`
+	Banner     string `json:"banner,omitempty"`
+}
+
+// CertificateDetails describes one certificate of the chain presented by a peer
+type CertificateDetails struct {
+	Subject         string    `json:"subject"`
+	Issuer          string    `json:"issuer"`
+	DNSNames        []string  `json:"dns_names,omitempty"`
+	NotBefore       time.Time `json:"not_before"`
+	NotAfter        time.Time `json:"not_after"`
+	DaysUntilExpiry int       `json:"days_until_expiry"`
+}
+
+// TlsDetails is what a probe observed during the TLS handshake
+type TlsDetails struct {
+	Version       string               `json:"version"`
+	ServerName    string               `json:"server_name,omitempty"`
+	Chain         []CertificateDetails `json:"chain"`
+	HostnameValid bool                 `json:"hostname_valid"`
+	Verified      bool                 `json:"verified"`
+	VerifyError   string               `json:"verify_error,omitempty"`
+	ExpiresSoon   bool                 `json:"expires_soon"`
+}
+
+// DnsDetails is what a dns probe received from the resolver
+type DnsDetails struct {
+	Resolver   string   `json:"resolver"`
+	RecordType string   `json:"record_type"`
+	RCode      string   `json:"rcode,omitempty"`
+	Answers    []string `json:"answers,omitempty"`
+}
+
+// RedirectHop is one redirect response an http probe followed
+type RedirectHop struct {
+	Url        string  `json:"url"`
+	StatusCode int     `json:"status_code"`
+	LatencyMs  float64 `json:"latency_ms"`
+}
+
+// ProbeAttempt is the outcome of one attempt at probing a url
+type ProbeAttempt struct {
+	Attempt    int     `json:"attempt"`
+	StatusCode int     `json:"status_code,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	LatencyMs  float64 `json:"latency_ms"`
+	BackoffMs  float64 `json:"backoff_ms,omitempty"`
+}
+
 type JobResult struct {
-	ID        string          `gorm:"primaryKey"`
-	JobID     string          `gorm:"not null;index"`
-	Job       Job             `gorm:"foreignKey:JobID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Url       string          `gorm:"not null"`
-	Status    JobResultStatus `gorm:"not null;default:0"`
-	LatencyMs int64           `gorm:"not null"`
-	CreatedAt time.Time       `gorm:"not null"`
-	UpdatedAt time.Time       `gorm:"not null"`
+	ID                string          `gorm:"primaryKey"`
+	JobID             string          `gorm:"not null;index"`
+	Job               Job             `gorm:"foreignKey:JobID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Url               string          `gorm:"not null"`
+	NormalizedUrl     string          `gorm:"not null;default:''"`
+	Scheme            string          `gorm:"not null;default:''"`
+	Status            JobResultStatus `gorm:"not null;default:0"`
+	LatencyMs         int64           `gorm:"not null"`
+	StatusCode        int             `gorm:"not null;default:0"`
+	Proto             string          `gorm:"not null;default:''"`
+	BytesRead         int64           `gorm:"not null;default:0"`
+	FinalUrl          string          `gorm:"not null;default:''"`
+	Redirects         []RedirectHop   `gorm:"type:jsonb;serializer:json"`
+	HttpsDowngrade    bool            `gorm:"not null;default:false"`
+	Proxy             string          `gorm:"not null;default:''"`
+	Error             string          `gorm:"not null;default:''"`
+	Timings           PhaseTimings    `gorm:"embedded;embeddedPrefix:timing_"`
+	AttemptCount      int             `gorm:"not null;default:0"`
+	Attempts          []ProbeAttempt  `gorm:"type:jsonb;serializer:json"`
+	AssertionFailures []string        `gorm:"type:jsonb;serializer:json"`
+	Tcp               *TcpDetails     `gorm:"type:jsonb;serializer:json"`
+	Tls               *TlsDetails     `gorm:"type:jsonb;serializer:json"`
+	Dns               *DnsDetails     `gorm:"type:jsonb;serializer:json"`
+	CreatedAt         time.Time       `gorm:"not null"`
+	UpdatedAt         time.Time       `gorm:"not null"`
 }
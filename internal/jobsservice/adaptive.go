@@ -0,0 +1,40 @@
+package jobsservice
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/alirezazahiri/gofetch-v2/pkg/worker"
+)
+
+// congestionClasses are the failures that make an adaptive job back off,
+// other errors say something about the target rather than its load
+var congestionClasses = []string{pinger.ErrorClassTimeout, pinger.ErrorClassReset, pinger.ErrorClassRefused}
+
+// isCongested reports whether a probe outcome hints at an overloaded target
+func isCongested(result *pinger.Result, err error) bool {
+	if err != nil && slices.Contains(congestionClasses, pinger.ClassifyError(err)) {
+		return true
+	}
+	if result != nil && result.HTTP != nil {
+		switch result.HTTP.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return true
+		}
+	}
+	return false
+}
+
+func newConcurrencyLimits(history []worker.LimitChange) []entity.ConcurrencyLimit {
+	limits := make([]entity.ConcurrencyLimit, len(history))
+	for i, change := range history {
+		limits[i] = entity.ConcurrencyLimit{
+			Host:  change.Key,
+			Limit: change.Limit,
+			AtMs:  change.At.Milliseconds(),
+		}
+	}
+	return limits
+}
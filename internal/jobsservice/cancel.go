@@ -0,0 +1,102 @@
+package jobsservice
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/pkg/jobsutils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("job already finished")
+)
+
+// Cancel stops a pending or running job. The job is flagged in the database
+// so the instance running it stops as well when that is not this one
+func (s *Service) Cancel(ctx context.Context, request *jobsdto.CancelRequest) (*jobsdto.CancelResponse, error) {
+	job, err := s.repo.GetJob(request.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.IsFinished() {
+		return nil, ErrJobFinished
+	}
+
+	requested, err := s.repo.RequestJobCancel(job.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !requested {
+		return nil, ErrJobFinished
+	}
+
+	log.Printf("JOB_CANCEL_REQUESTED: job=%s", job.ID)
+	s.cancelRunning(job.ID)
+
+	return &jobsdto.CancelResponse{
+		JobId:           job.ID,
+		Status:          jobsutils.MapJobStatusToString(job.Status),
+		CancelRequested: true,
+	}, nil
+}
+
+// track remembers how to cancel a job running on this instance
+func (s *Service) track(jobID string, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running[jobID] = cancel
+}
+
+func (s *Service) untrack(jobID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, jobID)
+}
+
+func (s *Service) cancelRunning(jobID string) {
+	s.mu.Lock()
+	cancel, ok := s.running[jobID]
+	s.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// watchCancel cancels the job once another instance flagged it, until ctx is done
+func (s *Service) watchCancel(ctx context.Context, jobID string, cancel context.CancelFunc) {
+	interval := time.Duration(s.cfg.CancelPollIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		requested, err := s.repo.IsJobCancelRequested(jobID)
+		if err != nil {
+			log.Println("CHECK_JOB_CANCEL_ERROR:", jobID, err)
+			continue
+		}
+		if requested {
+			log.Printf("JOB_CANCEL_OBSERVED: job=%s", jobID)
+			cancel()
+			return
+		}
+	}
+}
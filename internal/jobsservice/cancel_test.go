@@ -0,0 +1,68 @@
+package jobsservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkRequest(urls ...string) *jobsdto.CheckRequest {
+	return &jobsdto.CheckRequest{Urls: urls, Concurrency: 1, TimeoutMs: 5000}
+}
+
+func TestCancelStopsRunningJobAndRecordsUnprobedTargets(t *testing.T) {
+	server, started := startHangingServer(t)
+	repo := newFakeRepo()
+	svc := newTestService(t, repo)
+
+	response, err := svc.Check(context.Background(), checkRequest(server.URL+"/a", server.URL+"/b", server.URL+"/c"))
+	require.NoError(t, err)
+	<-started
+
+	cancelled, err := svc.Cancel(context.Background(), &jobsdto.CancelRequest{ID: response.JobId})
+	require.NoError(t, err)
+	assert.True(t, cancelled.CancelRequested)
+
+	job := waitFinished(t, repo, response.JobId)
+	assert.Equal(t, entity.JobStatusCancelled, job.Status)
+	// the probe in flight and the two never started are all recorded as cancelled
+	assert.Equal(t, []entity.JobResultStatus{
+		entity.JobResultStatusCancelled, entity.JobResultStatusCancelled, entity.JobResultStatusCancelled,
+	}, resultStatuses(repo.resultsOf(response.JobId)))
+	assert.Empty(t, job.LeaseOwner, "the lease is released once the job is done")
+}
+
+func TestRunningJobStopsWhenCancelledOnAnotherInstance(t *testing.T) {
+	server, started := startHangingServer(t)
+	repo := newFakeRepo()
+	svc := newTestService(t, repo)
+
+	response, err := svc.Check(context.Background(), checkRequest(server.URL+"/a", server.URL+"/b"))
+	require.NoError(t, err)
+	<-started
+
+	// the other instance only sets the flag, watchCancel has to notice it
+	repo.flagCancel(response.JobId)
+
+	job := waitFinished(t, repo, response.JobId)
+	assert.Equal(t, entity.JobStatusCancelled, job.Status)
+	assert.Equal(t, []entity.JobResultStatus{
+		entity.JobResultStatusCancelled, entity.JobResultStatusCancelled,
+	}, resultStatuses(repo.resultsOf(response.JobId)))
+}
+
+func TestCancelRefusesUnknownAndFinishedJobs(t *testing.T) {
+	repo := newFakeRepo()
+	svc := newTestService(t, repo)
+	require.NoError(t, repo.CreateJob(&entity.Job{ID: "done", Status: entity.JobStatusCompleted}))
+
+	_, err := svc.Cancel(context.Background(), &jobsdto.CancelRequest{ID: "missing"})
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	_, err = svc.Cancel(context.Background(), &jobsdto.CancelRequest{ID: "done"})
+	assert.ErrorIs(t, err, ErrJobFinished)
+}
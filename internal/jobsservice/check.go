@@ -2,6 +2,7 @@ package jobsservice
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log"
 	"sync"
@@ -10,123 +11,268 @@ import (
 
 	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
 	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
 	"github.com/alirezazahiri/gofetch-v2/pkg/jobsutils"
 	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
 	"github.com/alirezazahiri/gofetch-v2/pkg/uuid"
 	"github.com/alirezazahiri/gofetch-v2/pkg/worker"
 )
 
 type pingResult struct {
-	url       string
+	target    target
 	latencyMs int64
+	result    *pinger.Result
+	attempts  []entity.ProbeAttempt
 	err       error
 }
 
-const (
-	TimeoutError = "timeout"
-)
+// hostLimiterFor combines the global per-host limits with the ones of the job
+func (s *Service) hostLimiterFor(request *jobsdto.CheckRequest) hostlimit.Group {
+	limiter := hostlimit.Group{s.hostLimiter}
+	if request.HostLimit != nil {
+		limiter = append(limiter, hostlimit.New(hostlimit.Limits{
+			MaxConcurrent:     request.HostLimit.MaxConcurrent,
+			RequestsPerSecond: request.HostLimit.RequestsPerSecond,
+			Burst:             request.HostLimit.Burst,
+		}))
+	}
+	return limiter
+}
 
 func (s *Service) Check(ctx context.Context, request *jobsdto.CheckRequest) (*jobsdto.CheckResponse, error) {
+	if _, err := newProbeSpecs(request); err != nil {
+		return nil, err
+	}
+	if err := checkTargets(request); err != nil {
+		return nil, err
+	}
+
+	// the request is stored with the job so it can be resumed after a restart
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
 	job := &entity.Job{
 		ID:         uuid.New(),
 		Status:     entity.JobStatusPending,
+		Request:    encoded,
 		DurationMs: 0,
 		CreatedAt:  time.Now().UTC(),
 		UpdatedAt:  time.Now().UTC(),
 	}
+	// an instance that runs jobs keeps the ones it accepts, api only
+	// instances leave them queued for a worker to claim. Those never turn a
+	// job away, the workers stop claiming once their scheduler is full
+	if s.cfg.RunsJobs() {
+		expiresAt := time.Now().UTC().Add(s.cfg.leaseTTL())
+		job.LeaseOwner = s.instanceID
+		job.LeaseExpiresAt = &expiresAt
+	}
 
 	if err := s.repo.CreateJob(job); err != nil {
 		log.Println("CREATE_JOB_ERROR:", job.ID, err)
 		return nil, err
 	}
 
-	numberOfWorkers := jobsutils.NormalizeNumberOfWorkers(request.Concurrency)
+	if !s.cfg.RunsJobs() {
+		return &jobsdto.CheckResponse{
+			JobId:       job.ID,
+			Status:      jobsutils.MapJobStatusToString(job.Status),
+			Concurrency: jobsutils.NormalizeNumberOfWorkers(request.Concurrency, s.cfg.MaxConcurrencyPerJob),
+		}, nil
+	}
 
-	go func(jobID string) {
-		asyncCtx := context.Background()
-		start := time.Now()
-		var isRunning atomic.Bool
-
-		results := worker.Run(asyncCtx, request.Urls, numberOfWorkers, func(url string) pingResult {
-			if !isRunning.Swap(true) {
-				log.Printf("JOB_RUNNING: job=%s", jobID)
-				job.Status = entity.JobStatusRunning
-				job.UpdatedAt = time.Now().UTC()
-				if err := s.repo.UpdateJob(job); err != nil {
-					log.Println("UPDATE_JOB_TO_RUNNING_ERROR:", jobID, err)
-				}
-			}
+	numberOfWorkers, err := s.run(job, request, nil)
+	if err != nil {
+		if err := s.repo.DeleteJob(job.ID); err != nil {
+			log.Println("DELETE_REJECTED_JOB_ERROR:", job.ID, err)
+		}
+		return nil, err
+	}
 
-			pingStart := time.Now()
+	// job belongs to the run now, it may be finished already
+	return &jobsdto.CheckResponse{
+		JobId:       job.ID,
+		Status:      jobsutils.MapJobStatusToString(entity.JobStatusRunning),
+		Concurrency: numberOfWorkers,
+	}, nil
+}
 
-			pingCtx, cancel := context.WithTimeout(asyncCtx, time.Duration(request.TimeoutMs)*time.Millisecond)
-			defer cancel()
+// run probes the targets of request that have no result among previous yet
+// and finishes job once they are done. It returns the number of probes the
+// job may run at once
+func (s *Service) run(job *entity.Job, request *jobsdto.CheckRequest, previous []entity.JobResult) (int, error) {
+	specs, err := newProbeSpecs(request)
+	if err != nil {
+		return 0, err
+	}
 
-			errChan := make(chan error, 1)
-			go func() {
-				errChan <- pinger.Ping(url)
-			}()
+	// targets are read from the request as the probes get to them
+	targets := remainingTargets(newTargets(request), previous)
+
+	numberOfWorkers := jobsutils.NormalizeNumberOfWorkers(request.Concurrency, s.cfg.MaxConcurrencyPerJob, s.scheduler.Workers())
+
+	// the job outlives the request, it only stops early when cancelled
+	jobCtx, cancelJob := context.WithCancel(context.Background())
+	if job.CancelRequested {
+		cancelJob()
+	}
+	start := time.Now()
+	jobID := job.ID
+
+	// an adaptive job starts low and finds its own concurrency, numberOfWorkers
+	// only caps it
+	var limiter worker.Limiter = s.hostLimiterFor(request)
+	var adaptive *worker.Adaptive
+	if request.Adaptive {
+		adaptive = worker.NewAdaptive(worker.AdaptiveConfig{Max: numberOfWorkers})
+		limiter = worker.Chain{adaptive, limiter}
+	}
+
+	ticket, err := s.scheduler.Submit(scheduler.Job{
+		Weight:  request.Weight,
+		Size:    max(len(request.Urls)-len(previous), 0),
+		Limiter: limiter,
+	})
+	if err != nil {
+		log.Println("SUBMIT_JOB_ERROR:", jobID, err)
+		cancelJob()
+		return 0, err
+	}
+	s.track(jobID, cancelJob)
+
+	// lost is set once this instance no longer holds the lease on the job,
+	// from then on nothing of this run is written back
+	var lost atomic.Bool
+
+	// the status is set once up front, afterwards only the goroutine finishing
+	// the job writes to it
+	log.Printf("JOB_RUNNING: job=%s", jobID)
+	job.Status = entity.JobStatusRunning
+	job.UpdatedAt = time.Now().UTC()
+	if err := s.updateJob(job, &lost, cancelJob); err != nil {
+		log.Println("UPDATE_JOB_TO_RUNNING_ERROR:", jobID, err)
+	}
+
+	// every probe waits for a worker of the shared scheduler, a probe that
+	// panics is reported as a failed result instead of taking the process down.
+	// Targets are only taken once there is room for their results, and the
+	// ones of a host that is still waiting for a worker are held back so the
+	// other hosts keep going
+	inFlight := &targetWindow{}
+	lanes := newHostLanes()
+	results := worker.RunSeq(jobCtx, inFlight.track(lanes.order(jobCtx, targets)), worker.Options{Workers: numberOfWorkers}, func(ctx context.Context, t target) (pingResult, error) {
+		release, err := lanes.acquire(ctx, ticket, t)
+		if err != nil {
+			return pingResult{}, err
+		}
+		slot := &probeSlot{
+			release: release,
+			acquire: func(ctx context.Context) (func(), error) {
+				return lanes.reacquire(ctx, ticket, t)
+			},
+		}
+		defer slot.done()
+
+		pingStart := time.Now()
+
+		timeout := time.Duration(request.TimeoutMs) * time.Millisecond
+		pingRes, attempts, pingErr := s.probeWithRetry(ctx, t.normalized, specs.forURL(t.url), timeout, slot)
+		if pingErr != nil {
+			log.Printf("PING_ERROR: job=%s url=%s error=%v", jobID, t.normalized, pingErr)
+		}
+
+		latency := time.Since(pingStart)
+		if adaptive != nil && ctx.Err() == nil {
+			adaptive.Observe(targetHost(t), latency, isCongested(pingRes, pingErr))
+		}
+
+		return pingResult{
+			target:    t,
+			latencyMs: latency.Milliseconds(),
+			result:    pingRes,
+			attempts:  attempts,
+			err:       pingErr,
+		}, nil
+	})
+
+	go s.watchCancel(jobCtx, jobID, cancelJob)
+	go s.heartbeat(jobCtx, jobID, &lost, cancelJob)
 
-			var pingErr error
-			select {
-			case <-pingCtx.Done():
-				pingErr = pingCtx.Err()
-				if pingErr == context.DeadlineExceeded {
-					pingErr = errors.New(TimeoutError)
+	go func(jobID string) {
+		defer func() {
+			ticket.Done()
+			s.untrack(jobID)
+			cancelJob()
+		}()
+
+		countErrors := countFailedResults(previous)
+		countResults := len(previous)
+		wg := sync.WaitGroup{}
+		for taskResult := range results {
+			result := taskResult.Value
+			if taskResult.Err != nil {
+				var panicErr *worker.PanicError
+				if !errors.As(taskResult.Err, &panicErr) {
+					// the job was stopped before the probe got a worker
+					continue
 				}
-			case pingErr = <-errChan:
-				log.Printf("PING_ERROR: job=%s url=%s error=%v", jobID, url, pingErr)
+				t := inFlight.take(taskResult.Index)
+				log.Printf("PROBE_PANIC: job=%s url=%s error=%v\n%s", jobID, t.url, panicErr, panicErr.Stack)
+				result = pingResult{target: t, err: panicErr}
+			} else {
+				inFlight.take(taskResult.Index)
 			}
 
-			latency := time.Since(pingStart)
-
-			return pingResult{
-				url:       url,
-				latencyMs: latency.Milliseconds(),
-				err:       pingErr,
+			countResults++
+			jobResult := newJobResult(jobID, result, jobCtx.Err() != nil)
+			if result.err != nil && jobResult.Status != entity.JobResultStatusCancelled {
+				countErrors++
+				log.Printf("\n\nPING_ERROR: job=%s url=%s error=%v", jobID, result.target.url, result.err)
 			}
-		})
 
-		countErrors := 0
-		wg := sync.WaitGroup{}
-		for result := range results {
 			wg.Add(1)
-			go func(result pingResult) {
+			go func() {
 				defer wg.Done()
-				jobResult := &entity.JobResult{
-					ID:        uuid.New(),
-					JobID:     jobID,
-					Url:       result.url,
-					Status:    entity.JobResultStatusCompleted,
-					LatencyMs: result.latencyMs,
-					CreatedAt: time.Now().UTC(),
-					UpdatedAt: time.Now().UTC(),
+				if lost.Load() {
+					return
 				}
-
-				if result.err != nil {
-					if result.err.Error() == TimeoutError {
-						jobResult.Status = entity.JobResultStatusTimeout
-					} else {
-						jobResult.Status = entity.JobResultStatusFailed
-						jobResult.LatencyMs = 0
-					}
-					countErrors++
-					log.Printf("\n\nPING_ERROR: job=%s url=%s error=%v", jobID, result.url, result.err)
+				if err := s.createJobResult(jobResult, &lost, cancelJob); err != nil {
+					log.Println("\n\nCREATE_JOB_RESULT_ERROR:", jobID, jobResult.Url, err)
 				}
+			}()
+		}
+
+		wg.Wait()
 
-				if err := s.repo.CreateJobResult(jobResult); err != nil {
-					log.Println("\n\nCREATE_JOB_RESULT_ERROR:", jobID, result.url, err)
+		cancelled := jobCtx.Err() != nil
+		if cancelled {
+			for t := range inFlight.unfinished(targets, lanes.heldTargets()) {
+				if lost.Load() {
+					break
 				}
-			}(result)
+				s.createCancelledResult(jobID, t, &lost, cancelJob)
+			}
 		}
 
-		wg.Wait()
+		if lost.Load() {
+			log.Printf("\n\nJOB_ABANDONED: job=%s", jobID)
+			return
+		}
 
 		duration := time.Since(start)
-		job.DurationMs = duration.Milliseconds()
+		job.DurationMs += duration.Milliseconds()
+		if adaptive != nil {
+			job.Metadata = &entity.JobMetadata{ConcurrencyLimits: newConcurrencyLimits(adaptive.History())}
+		}
 		job.UpdatedAt = time.Now().UTC()
 
-		if countErrors == len(request.Urls) {
+		if cancelled {
+			job.Status = entity.JobStatusCancelled
+			log.Printf("\n\nJOB_CANCELLED: job=%s countErrors=%d", jobID, countErrors)
+		} else if countErrors == countResults {
 			job.Status = entity.JobStatusFailed
 			log.Printf("\n\nJOB_FAILED: job=%s countErrors=%d", jobID, countErrors)
 		} else {
@@ -135,13 +281,107 @@ func (s *Service) Check(ctx context.Context, request *jobsdto.CheckRequest) (*jo
 		}
 
 		log.Printf("\n\nUPDATE_JOB_FINAL_STATUS: job=%s status=%s countErrors=%d", jobID, jobsutils.MapJobStatusToString(job.Status), countErrors)
-		if err := s.repo.UpdateJob(job); err != nil {
+		if err := s.updateJob(job, &lost, cancelJob); err != nil {
 			log.Println("UPDATE_JOB_FINAL_STATUS_ERROR:", jobID, err)
 		}
+		if lost.Load() {
+			log.Printf("\n\nJOB_ABANDONED: job=%s", jobID)
+			return
+		}
+		if err := s.repo.ReleaseJobLease(jobID, s.instanceID); err != nil {
+			log.Println("RELEASE_JOB_LEASE_ERROR:", jobID, err)
+		}
 	}(job.ID)
 
-	return &jobsdto.CheckResponse{
-		JobId:  job.ID,
-		Status: jobsutils.MapJobStatusToString(job.Status),
-	}, nil
+	return numberOfWorkers, nil
+}
+
+// newJobResult builds the result of one probe, a probe that failed once the
+// job was cancelled counts as cancelled rather than failed
+func newJobResult(jobID string, result pingResult, cancelled bool) *entity.JobResult {
+	jobResult := &entity.JobResult{
+		ID:            uuid.New(),
+		JobID:         jobID,
+		Url:           result.target.url,
+		NormalizedUrl: result.target.normalized,
+		Status:        entity.JobResultStatusCompleted,
+		LatencyMs:     result.latencyMs,
+		AttemptCount:  len(result.attempts),
+		Attempts:      result.attempts,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+
+	if result.result != nil {
+		applyProbeResult(jobResult, result.result)
+		if result.err == nil && result.result.Degraded() {
+			jobResult.Status = entity.JobResultStatusDegraded
+		}
+	}
+
+	if result.err != nil && cancelled {
+		jobResult.Error = pinger.NormalizeError(result.err)
+		jobResult.Status = entity.JobResultStatusCancelled
+	} else if result.err != nil {
+		jobResult.Error = pinger.NormalizeError(result.err)
+		jobResult.Status = jobResultStatusFor(result.err)
+		if jobResult.Status != entity.JobResultStatusTimeout {
+			jobResult.LatencyMs = 0
+		}
+	}
+
+	return jobResult
+}
+
+// updateJob saves job as long as this instance holds the lease on it. Once
+// it does not the run is marked lost and stopped, so the status and duration
+// of the new owner are never overwritten
+func (s *Service) updateJob(job *entity.Job, lost *atomic.Bool, cancel context.CancelFunc) error {
+	held, err := s.repo.UpdateLeasedJob(job, s.instanceID)
+	if err != nil {
+		return err
+	}
+	if !held {
+		s.loseLease(job.ID, lost, cancel)
+	}
+	return nil
+}
+
+// createJobResult stores jobResult as long as this instance holds the lease
+// on its job. Once it does not the run is marked lost and stopped, so a
+// result is never written by two instances
+func (s *Service) createJobResult(jobResult *entity.JobResult, lost *atomic.Bool, cancel context.CancelFunc) error {
+	held, err := s.repo.CreateLeasedJobResult(jobResult, s.instanceID)
+	if err != nil {
+		return err
+	}
+	if !held {
+		s.loseLease(jobResult.JobID, lost, cancel)
+	}
+	return nil
+}
+
+// loseLease marks the run of jobID lost and stops it, once
+func (s *Service) loseLease(jobID string, lost *atomic.Bool, cancel context.CancelFunc) {
+	if !lost.Swap(true) {
+		log.Printf("JOB_LEASE_LOST: job=%s instance=%s", jobID, s.instanceID)
+		cancel()
+	}
+}
+
+// createCancelledResult records a target the job was cancelled before probing
+func (s *Service) createCancelledResult(jobID string, t target, lost *atomic.Bool, cancel context.CancelFunc) {
+	jobResult := &entity.JobResult{
+		ID:            uuid.New(),
+		JobID:         jobID,
+		Url:           t.url,
+		NormalizedUrl: t.normalized,
+		Status:        entity.JobResultStatusCancelled,
+		AttemptCount:  0,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
+	}
+	if err := s.createJobResult(jobResult, lost, cancel); err != nil {
+		log.Println("CREATE_CANCELLED_JOB_RESULT_ERROR:", jobID, t.url, err)
+	}
 }
@@ -0,0 +1,63 @@
+package jobsservice
+
+import (
+	"fmt"
+	"time"
+)
+
+// Roles an instance can run under. API instances only accept jobs and leave
+// them queued, workers only claim and run queued jobs, RoleAll does both
+const (
+	RoleAll    = "all"
+	RoleAPI    = "api"
+	RoleWorker = "worker"
+)
+
+// Config sets how many probes may run at once. MaxConcurrencyPerJob caps the
+// probes of a single job, zero leaving it to the scheduler. GlobalConcurrency
+// is the number of scheduler workers shared by every job and MaxQueuedProbes
+// how many probes may wait for one before new jobs are turned away. Only
+// instances that run jobs turn jobs away, an api only instance stores every
+// job it accepts and workers stop claiming while their queue is full.
+// CancelPollIntervalMs is how often running jobs look for cancel requests
+// made on other instances, zero turning that off.
+//
+// Jobs are leased to the instance running them for LeaseTTLMs and the lease
+// is renewed every HeartbeatIntervalMs, a job whose lease ran out is claimed
+// by the next worker looking for work every ClaimIntervalMs, up to
+// ClaimBatchSize jobs at a time. InstanceID names the lease owner and is
+// generated when left empty
+type Config struct {
+	MaxConcurrencyPerJob int    `koanf:"max_concurrency_per_job"`
+	GlobalConcurrency    int    `koanf:"global_concurrency"`
+	MaxQueuedProbes      int    `koanf:"max_queued_probes"`
+	CancelPollIntervalMs int    `koanf:"cancel_poll_interval_ms"`
+	Role                 string `koanf:"role"`
+	InstanceID           string `koanf:"instance_id"`
+	LeaseTTLMs           int    `koanf:"lease_ttl_ms"`
+	HeartbeatIntervalMs  int    `koanf:"heartbeat_interval_ms"`
+	ClaimIntervalMs      int    `koanf:"claim_interval_ms"`
+	ClaimBatchSize       int    `koanf:"claim_batch_size"`
+}
+
+// Validate reports settings the service cannot run with
+func (c *Config) Validate() error {
+	switch c.Role {
+	case RoleAll, RoleAPI, RoleWorker:
+	default:
+		return fmt.Errorf("unknown role %q", c.Role)
+	}
+	if c.RunsJobs() && (c.LeaseTTLMs <= 0 || c.HeartbeatIntervalMs <= 0 || c.HeartbeatIntervalMs >= c.LeaseTTLMs) {
+		return fmt.Errorf("heartbeat_interval_ms must be positive and below lease_ttl_ms")
+	}
+	return nil
+}
+
+// RunsJobs reports whether the instance claims and runs jobs
+func (c *Config) RunsJobs() bool {
+	return c.Role != RoleAPI
+}
+
+func (c *Config) leaseTTL() time.Duration {
+	return time.Duration(c.LeaseTTLMs) * time.Millisecond
+}
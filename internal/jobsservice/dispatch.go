@@ -0,0 +1,78 @@
+package jobsservice
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Dispatch claims queued jobs and jobs whose lease ran out, left behind by an
+// api only instance or by one that died, and runs them until ctx is done.
+// Urls of a job that already have a result are not probed again
+func (s *Service) Dispatch(ctx context.Context) {
+	interval := time.Duration(s.cfg.ClaimIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	log.Printf("DISPATCHER_STARTED: instance=%s", s.instanceID)
+	for {
+		s.claim()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// claim leases as many jobs as the scheduler has room for and starts them
+func (s *Service) claim() {
+	if s.cfg.MaxQueuedProbes > 0 && s.scheduler.Queued() >= s.cfg.MaxQueuedProbes {
+		return
+	}
+
+	jobs, err := s.repo.ClaimJobs(s.instanceID, s.cfg.leaseTTL(), max(s.cfg.ClaimBatchSize, 1))
+	if err != nil {
+		log.Println("CLAIM_JOBS_ERROR:", err)
+		return
+	}
+
+	for _, job := range jobs {
+		if err := s.resume(job.ID); err != nil {
+			// let another instance, or this one later, have a go at it
+			log.Println("RESUME_JOB_ERROR:", job.ID, err)
+			if err := s.repo.ReleaseJobLease(job.ID, s.instanceID); err != nil {
+				log.Println("RELEASE_JOB_LEASE_ERROR:", job.ID, err)
+			}
+		}
+	}
+}
+
+// heartbeat renews the lease on a running job until ctx is done. When the
+// lease turns out to be lost another instance runs the job by now, so this
+// run is marked lost and stopped
+func (s *Service) heartbeat(ctx context.Context, jobID string, lost *atomic.Bool, cancel context.CancelFunc) {
+	ticker := time.NewTicker(time.Duration(s.cfg.HeartbeatIntervalMs) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		held, err := s.repo.RenewJobLease(jobID, s.instanceID, s.cfg.leaseTTL())
+		if err != nil {
+			log.Println("RENEW_JOB_LEASE_ERROR:", jobID, err)
+			continue
+		}
+		if !held {
+			s.loseLease(jobID, lost, cancel)
+			return
+		}
+	}
+}
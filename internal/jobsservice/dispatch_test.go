@@ -0,0 +1,137 @@
+package jobsservice
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitUntracked waits for the run of a job on svc to return
+func waitUntracked(t *testing.T, svc *Service, id string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		_, running := svc.running[id]
+		return !running
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestRunWritesNothingOnceItsLeaseIsTakenOver(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	repo := newFakeRepo()
+	svc := newTestService(t, repo)
+	response, err := svc.Check(context.Background(), checkRequest(server.URL+"/a", server.URL+"/b", server.URL+"/c"))
+	require.NoError(t, err)
+	<-started
+
+	// the lease ran out and another worker claimed the job while the probe was running
+	repo.takeOver(response.JobId, "other-instance")
+	close(release)
+	waitUntracked(t, svc, response.JobId)
+
+	assert.Empty(t, repo.resultsOf(response.JobId))
+	job, err := repo.GetJob(response.JobId)
+	require.NoError(t, err)
+	assert.Equal(t, entity.JobStatusRunning, job.Status, "the job is left for the new owner to finish")
+	assert.Equal(t, "other-instance", job.LeaseOwner)
+}
+
+// takeOverAfterResults hands the job to another instance right after the
+// run wrote its last result, before it saves the final status
+type takeOverAfterResults struct {
+	*fakeRepo
+	results int
+	written atomic.Int32
+}
+
+func (r *takeOverAfterResults) CreateLeasedJobResult(jobResult *entity.JobResult, owner string) (bool, error) {
+	held, err := r.fakeRepo.CreateLeasedJobResult(jobResult, owner)
+	if held && int(r.written.Add(1)) == r.results {
+		r.takeOver(jobResult.JobID, "other-instance")
+	}
+	return held, err
+}
+
+func TestRunLeavesFinalStatusToTheNewOwner(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	repo := &takeOverAfterResults{fakeRepo: newFakeRepo(), results: 2}
+	svc := newTestService(t, repo)
+	response, err := svc.Check(context.Background(), checkRequest(server.URL+"/a", server.URL+"/b"))
+	require.NoError(t, err)
+	waitUntracked(t, svc, response.JobId)
+
+	assert.Len(t, repo.resultsOf(response.JobId), 2)
+	job, err := repo.GetJob(response.JobId)
+	require.NoError(t, err)
+	assert.Equal(t, entity.JobStatusRunning, job.Status, "the stale run does not finish the job of the new owner")
+	assert.Zero(t, job.DurationMs)
+	assert.Equal(t, "other-instance", job.LeaseOwner)
+}
+
+func TestHeartbeatStopsRunWhoseLeaseIsTakenOver(t *testing.T) {
+	server, started := startHangingServer(t)
+	repo := newFakeRepo()
+	svc := newTestService(t, repo)
+	svc.cfg.HeartbeatIntervalMs = 10
+
+	response, err := svc.Check(context.Background(), checkRequest(server.URL+"/a", server.URL+"/b"))
+	require.NoError(t, err)
+	<-started
+
+	repo.takeOver(response.JobId, "other-instance")
+	waitUntracked(t, svc, response.JobId)
+
+	assert.Empty(t, repo.resultsOf(response.JobId))
+	job, err := repo.GetJob(response.JobId)
+	require.NoError(t, err)
+	assert.Equal(t, entity.JobStatusRunning, job.Status)
+	assert.Equal(t, "other-instance", job.LeaseOwner)
+}
+
+func TestClaimTakesOverExpiredLeasesOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	repo := newFakeRepo()
+	svc := newTestService(t, repo)
+	request, err := json.Marshal(checkRequest(server.URL))
+	require.NoError(t, err)
+
+	expired := time.Now().UTC().Add(-time.Second)
+	live := time.Now().UTC().Add(time.Minute)
+	require.NoError(t, repo.CreateJob(&entity.Job{ID: "orphaned", Status: entity.JobStatusRunning, Request: request, LeaseOwner: "dead-instance", LeaseExpiresAt: &expired}))
+	require.NoError(t, repo.CreateJob(&entity.Job{ID: "leased", Status: entity.JobStatusRunning, Request: request, LeaseOwner: "live-instance", LeaseExpiresAt: &live}))
+
+	svc.claim()
+
+	job := waitFinished(t, repo, "orphaned")
+	assert.Equal(t, entity.JobStatusCompleted, job.Status)
+	assert.Empty(t, job.LeaseOwner)
+
+	job, err = repo.GetJob("leased")
+	require.NoError(t, err)
+	assert.Equal(t, entity.JobStatusRunning, job.Status)
+	assert.Equal(t, "live-instance", job.LeaseOwner)
+	assert.Empty(t, repo.resultsOf("leased"))
+}
@@ -0,0 +1,145 @@
+package jobsservice
+
+import (
+	"context"
+	"iter"
+	"sync"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
+)
+
+// maxHeldTargets caps how many targets a job holds back behind busy hosts,
+// once that many are held no more are read until one of them can go
+const maxHeldTargets = 256
+
+// hostLanes keeps a throttled host from parking every worker of a job. A
+// target is held back while an earlier target of its host was handed to
+// the pool but has no worker of the scheduler yet, so the targets of other
+// hosts go ahead of it
+type hostLanes struct {
+	mu sync.Mutex
+	// pending counts the targets of each host handed out and not started yet
+	pending map[string]int
+	held    []target
+	changed chan struct{}
+}
+
+func newHostLanes() *hostLanes {
+	return &hostLanes{
+		pending: make(map[string]int),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// order yields targets, holding back the ones whose host has a target
+// pending, until ctx is done
+func (l *hostLanes) order(ctx context.Context, targets iter.Seq[target]) iter.Seq[target] {
+	return func(yield func(target) bool) {
+		next, stop := iter.Pull(targets)
+		defer stop()
+
+		exhausted := false
+		for {
+			t, ok, pull := l.free(exhausted)
+			if pull {
+				var more bool
+				t, more = next()
+				if !more {
+					exhausted = true
+					continue
+				}
+				if !l.admit(t) {
+					continue
+				}
+				ok = true
+			}
+
+			if !ok {
+				if exhausted && l.heldCount() == 0 {
+					return
+				}
+				// every target left waits for its host
+				select {
+				case <-ctx.Done():
+					return
+				case <-l.changed:
+				}
+				continue
+			}
+
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// free takes the first held target whose host has nothing pending, else
+// reports whether another target may be read
+func (l *hostLanes) free(exhausted bool) (target, bool, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, t := range l.held {
+		host := targetHost(t)
+		if l.pending[host] == 0 {
+			l.held = append(l.held[:i], l.held[i+1:]...)
+			l.pending[host]++
+			return t, true, false
+		}
+	}
+	return target{}, false, !exhausted && len(l.held) < maxHeldTargets
+}
+
+// admit marks t pending when its host has nothing pending and holds it back
+// otherwise
+func (l *hostLanes) admit(t target) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	host := targetHost(t)
+	if l.pending[host] > 0 {
+		l.held = append(l.held, t)
+		return false
+	}
+	l.pending[host]++
+	return true
+}
+
+func (l *hostLanes) heldCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.held)
+}
+
+// heldTargets returns the targets held back and never handed out
+func (l *hostLanes) heldTargets() []target {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]target(nil), l.held...)
+}
+
+// acquire waits for a worker of ticket for t, which order handed out, and
+// lets the next target of its host through once it got one or gave up
+func (l *hostLanes) acquire(ctx context.Context, ticket *scheduler.Ticket, t target) (func(), error) {
+	host := targetHost(t)
+	release, err := ticket.Acquire(ctx, host)
+
+	l.mu.Lock()
+	l.pending[host]--
+	l.mu.Unlock()
+	select {
+	case l.changed <- struct{}{}:
+	default:
+	}
+	return release, err
+}
+
+// reacquire waits for another worker for t once it gave its first one back,
+// holding back the other targets of its host meanwhile like order does
+func (l *hostLanes) reacquire(ctx context.Context, ticket *scheduler.Ticket, t target) (func(), error) {
+	l.mu.Lock()
+	l.pending[targetHost(t)]++
+	l.mu.Unlock()
+	return l.acquire(ctx, ticket, t)
+}
@@ -0,0 +1,78 @@
+package jobsservice
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostLanesHoldBackTargetsOfPendingHosts(t *testing.T) {
+	request := &jobsdto.CheckRequest{Urls: []string{"http://a/1", "http://a/2", "http://b/1", "http://c/1"}}
+	targets := newTargets(request)
+	lanes := newHostLanes()
+	window := &targetWindow{}
+
+	// nothing got a worker yet so the second target of a waits behind the first
+	var handed []target
+	for next := range window.track(lanes.order(context.Background(), targets)) {
+		handed = append(handed, next)
+		if len(handed) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"http://a/1", "http://b/1"}, urlsOf(handed))
+	assert.Equal(t, []string{"http://a/2"}, urlsOf(lanes.heldTargets()))
+
+	window.take(0)
+	assert.Equal(t, []string{"http://b/1", "http://a/2", "http://c/1"},
+		urlsOf(slices.Collect(window.unfinished(targets, lanes.heldTargets()))))
+}
+
+func TestThrottledHostDoesNotHoldUpOtherHosts(t *testing.T) {
+	var mu sync.Mutex
+	started := make(map[string][]time.Time)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := strings.Cut(r.Host, ":")
+		mu.Lock()
+		started[host] = append(started[host], time.Now())
+		mu.Unlock()
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+	_, port, _ := strings.Cut(server.Listener.Addr().String(), ":")
+
+	repo := newFakeRepo()
+	svc := newTestService(t, repo)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.scheduler = scheduler.New(scheduler.Config{Workers: 64})
+	go svc.scheduler.Run(ctx)
+
+	// the leading urls share one host that only takes one probe at a time
+	request := &jobsdto.CheckRequest{Concurrency: 4, TimeoutMs: 5000, HostLimit: &jobsdto.HostLimitSpec{MaxConcurrent: 1}}
+	for i := 0; i < 8; i++ {
+		request.Urls = append(request.Urls, "http://127.0.0.1:"+port+"/"+string(rune('a'+i)))
+	}
+	request.Urls = append(request.Urls, "http://localhost:"+port+"/b")
+
+	begin := time.Now()
+	response, err := svc.Check(context.Background(), request)
+	require.NoError(t, err)
+	waitFinished(t, repo, response.JobId)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, started["127.0.0.1"], 8)
+	require.Len(t, started["localhost"], 1)
+	assert.Less(t, started["localhost"][0].Sub(begin), 50*time.Millisecond, "the other host starts right away")
+}
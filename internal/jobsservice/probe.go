@@ -0,0 +1,342 @@
+package jobsservice
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+)
+
+// probeSpecs resolves the spec each url of a job is probed with
+type probeSpecs struct {
+	base   *pinger.Spec
+	perURL map[string]*pinger.Spec
+}
+
+func newProbeSpecs(request *jobsdto.CheckRequest) (*probeSpecs, error) {
+	base, err := newProbeSpec(request.Probe)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := &probeSpecs{
+		base:   base,
+		perURL: make(map[string]*pinger.Spec, len(request.UrlProbes)),
+	}
+
+	for url, override := range request.UrlProbes {
+		spec, err := newProbeSpec(mergeProbeSpec(request.Probe, &override))
+		if err != nil {
+			return nil, fmt.Errorf("url %s: %w", url, err)
+		}
+		specs.perURL[url] = spec
+	}
+
+	return specs, nil
+}
+
+func (p *probeSpecs) forURL(url string) *pinger.Spec {
+	if spec, ok := p.perURL[url]; ok {
+		return spec
+	}
+	return p.base
+}
+
+// mergeProbeSpec returns override with every unset field taken from base
+func mergeProbeSpec(base, override *jobsdto.ProbeSpec) *jobsdto.ProbeSpec {
+	if base == nil {
+		return override
+	}
+
+	merged := *override
+	if merged.Method == "" {
+		merged.Method = base.Method
+	}
+	if merged.Headers == nil {
+		merged.Headers = base.Headers
+	}
+	if merged.Body == "" {
+		merged.Body = base.Body
+	}
+	if merged.ExpectedStatus == nil {
+		merged.ExpectedStatus = base.ExpectedStatus
+	}
+	if merged.Assertions == nil {
+		merged.Assertions = base.Assertions
+	}
+	if merged.Tcp == nil {
+		merged.Tcp = base.Tcp
+	}
+	if merged.Tls == nil {
+		merged.Tls = base.Tls
+	}
+	if merged.Dns == nil {
+		merged.Dns = base.Dns
+	}
+	if merged.Redirect == nil {
+		merged.Redirect = base.Redirect
+	}
+	if merged.Retry == nil {
+		merged.Retry = base.Retry
+	}
+	if merged.Proxy == nil {
+		merged.Proxy = base.Proxy
+	}
+
+	return &merged
+}
+
+// newProbeSpec converts the probe part of a check request into a pinger spec
+func newProbeSpec(probe *jobsdto.ProbeSpec) (*pinger.Spec, error) {
+	if probe == nil {
+		return &pinger.Spec{}, nil
+	}
+
+	spec := &pinger.Spec{
+		Method:  probe.Method,
+		Headers: probe.Headers,
+		Body:    probe.Body,
+	}
+
+	for _, value := range probe.ExpectedStatus {
+		statusRange, err := pinger.ParseStatusRange(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expected status: %w", err)
+		}
+		spec.ExpectedStatus = append(spec.ExpectedStatus, statusRange)
+	}
+
+	if probe.Assertions != nil {
+		assertions, err := newAssertions(probe.Assertions)
+		if err != nil {
+			return nil, err
+		}
+		spec.Assertions = assertions
+	}
+
+	if probe.Tcp != nil {
+		tcpSpec, err := newTCPSpec(probe.Tcp)
+		if err != nil {
+			return nil, err
+		}
+		spec.TCP = tcpSpec
+	}
+
+	if probe.Tls != nil {
+		spec.TLS = &pinger.TLSSpec{
+			ExpiryWarningDays: probe.Tls.ExpiryWarningDays,
+			Profile:           probe.Tls.Profile,
+		}
+	}
+
+	if probe.Dns != nil {
+		spec.DNS = &pinger.DNSSpec{
+			RecordType: probe.Dns.RecordType,
+			Resolver:   probe.Dns.Resolver,
+			Expected:   probe.Dns.Expected,
+		}
+	}
+
+	if probe.Redirect != nil {
+		spec.Redirect = &pinger.RedirectSpec{
+			Mode:    probe.Redirect.Mode,
+			MaxHops: probe.Redirect.MaxHops,
+		}
+	}
+
+	if probe.Retry != nil {
+		retry, err := newRetryPolicy(probe.Retry)
+		if err != nil {
+			return nil, err
+		}
+		spec.Retry = retry
+	}
+
+	if probe.Proxy != nil {
+		spec.Proxy = &pinger.ProxyConfig{
+			HTTPProxy:  probe.Proxy.HttpProxy,
+			HTTPSProxy: probe.Proxy.HttpsProxy,
+			NoProxy:    strings.Join(probe.Proxy.NoProxy, ","),
+			Direct:     probe.Proxy.Direct,
+		}
+	}
+
+	return spec, nil
+}
+
+func newRetryPolicy(request *jobsdto.RetrySpec) (*pinger.RetryPolicy, error) {
+	policy := &pinger.RetryPolicy{
+		MaxAttempts: request.MaxAttempts,
+		BackoffBase: time.Duration(request.BackoffBaseMs) * time.Millisecond,
+		BackoffCap:  time.Duration(request.BackoffCapMs) * time.Millisecond,
+		Jitter:      request.Jitter,
+		RetryOn:     request.RetryOn,
+	}
+
+	for _, value := range request.RetryStatus {
+		statusRange, err := pinger.ParseStatusRange(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry status: %w", err)
+		}
+		policy.RetryStatus = append(policy.RetryStatus, statusRange)
+	}
+
+	return policy, nil
+}
+
+func newTCPSpec(request *jobsdto.TcpSpec) (*pinger.TCPSpec, error) {
+	tcpSpec := &pinger.TCPSpec{
+		Send:   request.Send,
+		Expect: request.Expect,
+	}
+
+	if request.ExpectRegex != "" {
+		compiled, err := regexp.Compile(request.ExpectRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tcp expect pattern: %w", err)
+		}
+		tcpSpec.ExpectRegex = compiled
+	}
+
+	return tcpSpec, nil
+}
+
+func newAssertions(request *jobsdto.AssertionsSpec) (*pinger.Assertions, error) {
+	assertions := &pinger.Assertions{
+		BodyContains:    request.BodyContains,
+		BodyNotContains: request.BodyNotContains,
+		MaxBodyBytes:    request.MaxBodyBytes,
+	}
+
+	for _, pattern := range request.BodyMatches {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body pattern: %w", err)
+		}
+		assertions.BodyMatches = append(assertions.BodyMatches, compiled)
+	}
+
+	for _, item := range request.JSONPath {
+		path, err := pinger.ParseJSONPath(item.Path)
+		if err != nil {
+			return nil, err
+		}
+		assertions.JSONPath = append(assertions.JSONPath, pinger.JSONPathAssertion{
+			Path:   path,
+			Equals: item.Equals,
+		})
+	}
+
+	return assertions, nil
+}
+
+// jobResultStatusFor maps a probe error onto the status stored on the job result
+func jobResultStatusFor(err error) entity.JobResultStatus {
+	switch pinger.ClassifyError(err) {
+	case pinger.ErrorClassTimeout:
+		return entity.JobResultStatusTimeout
+	case pinger.ErrorClassRefused:
+		return entity.JobResultStatusRefused
+	case pinger.ErrorClassReset:
+		return entity.JobResultStatusReset
+	case pinger.ErrorClassNXDomain:
+		return entity.JobResultStatusNXDomain
+	case pinger.ErrorClassServFail:
+		return entity.JobResultStatusServFail
+	case pinger.ErrorClassDNSMismatch:
+		return entity.JobResultStatusMismatch
+	case pinger.ErrorClassBlocked:
+		return entity.JobResultStatusBlocked
+	}
+
+	return entity.JobResultStatusFailed
+}
+
+// applyProbeResult copies what the prober observed onto the job result
+func applyProbeResult(jobResult *entity.JobResult, result *pinger.Result) {
+	jobResult.Scheme = result.Scheme
+	jobResult.Timings = newPhaseTimings(result.Timings)
+	if result.Timings.Total > 0 {
+		jobResult.LatencyMs = result.Timings.Total.Milliseconds()
+	}
+
+	if result.HTTP != nil {
+		jobResult.StatusCode = result.HTTP.StatusCode
+		jobResult.Proto = result.HTTP.Proto
+		jobResult.BytesRead = result.HTTP.BytesRead
+		jobResult.FinalUrl = result.HTTP.FinalURL
+		jobResult.AssertionFailures = result.HTTP.AssertionFailures
+		jobResult.HttpsDowngrade = result.HTTP.HTTPSDowngrade
+		jobResult.Proxy = result.HTTP.Proxy
+		for _, hop := range result.HTTP.Redirects {
+			jobResult.Redirects = append(jobResult.Redirects, entity.RedirectHop{
+				Url:        hop.URL,
+				StatusCode: hop.StatusCode,
+				LatencyMs:  durationToMs(hop.Latency),
+			})
+		}
+	}
+
+	if result.TCP != nil {
+		jobResult.Tcp = &entity.TcpDetails{
+			RemoteAddr: result.TCP.RemoteAddr,
+			Banner:     result.TCP.Banner,
+		}
+	}
+
+	if result.TLS != nil {
+		jobResult.Tls = newTlsDetails(result.TLS)
+	}
+
+	if result.DNS != nil {
+		jobResult.Dns = &entity.DnsDetails{
+			Resolver:   result.DNS.Resolver,
+			RecordType: result.DNS.RecordType,
+			RCode:      result.DNS.RCode,
+			Answers:    result.DNS.Answers,
+		}
+	}
+}
+
+func newTlsDetails(result *pinger.TLSResult) *entity.TlsDetails {
+	details := &entity.TlsDetails{
+		Version:       result.Version,
+		ServerName:    result.ServerName,
+		HostnameValid: result.HostnameValid,
+		Verified:      result.Verified,
+		VerifyError:   result.VerifyError,
+		ExpiresSoon:   result.ExpiresSoon,
+	}
+
+	for _, cert := range result.Chain {
+		details.Chain = append(details.Chain, entity.CertificateDetails{
+			Subject:         cert.Subject,
+			Issuer:          cert.Issuer,
+			DNSNames:        cert.DNSNames,
+			NotBefore:       cert.NotBefore,
+			NotAfter:        cert.NotAfter,
+			DaysUntilExpiry: cert.DaysUntilExpiry,
+		})
+	}
+
+	return details
+}
+
+// newPhaseTimings converts pinger timings into the millisecond values stored on a job result
+func newPhaseTimings(timings pinger.Timings) entity.PhaseTimings {
+	return entity.PhaseTimings{
+		DnsMs:      durationToMs(timings.DNSLookup),
+		ConnectMs:  durationToMs(timings.TCPConnect),
+		TlsMs:      durationToMs(timings.TLSHandshake),
+		TtfbMs:     durationToMs(timings.TimeToFirstByte),
+		TransferMs: durationToMs(timings.Transfer),
+	}
+}
+
+func durationToMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
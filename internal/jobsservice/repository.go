@@ -0,0 +1,29 @@
+package jobsservice
+
+import (
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+)
+
+// Repository stores jobs, their results and the leases of the instances
+// running them. jobsrepo implements it on postgres
+type Repository interface {
+	CreateJob(job *entity.Job) error
+	// UpdateLeasedJob only saves job while owner holds a live lease on it,
+	// so a run that lost its job cannot overwrite the status of the new owner
+	UpdateLeasedJob(job *entity.Job, owner string) (bool, error)
+	DeleteJob(id string) error
+	GetJob(id string) (*entity.Job, error)
+	GetJobWithResults(jobID string) (*entity.Job, error)
+	// CreateLeasedJobResult only stores jobResult while owner holds a live
+	// lease on its job, so a run that lost its job cannot add to it
+	CreateLeasedJobResult(jobResult *entity.JobResult, owner string) (bool, error)
+
+	RequestJobCancel(id string) (bool, error)
+	IsJobCancelRequested(id string) (bool, error)
+
+	ClaimJobs(owner string, ttl time.Duration, limit int) ([]entity.Job, error)
+	RenewJobLease(id, owner string, ttl time.Duration) (bool, error)
+	ReleaseJobLease(id, owner string) error
+}
@@ -0,0 +1,37 @@
+package jobsservice
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+)
+
+// resume runs a job again from the request stored with it. Its urls that
+// already have a result are not probed again and those results still count
+// towards the final status of the job
+func (s *Service) resume(jobID string) error {
+	job, err := s.repo.GetJobWithResults(jobID)
+	if err != nil {
+		return err
+	}
+	// the results are passed on separately, saving the job must not write them back
+	previous := job.JobResults
+	job.JobResults = nil
+
+	var request jobsdto.CheckRequest
+	if err := json.Unmarshal(job.Request, &request); err != nil || len(job.Request) == 0 {
+		// jobs submitted before requests were stored cannot be run again
+		log.Printf("JOB_NOT_RESUMABLE: job=%s error=%v", job.ID, err)
+		job.Status = entity.JobStatusFailed
+		job.UpdatedAt = time.Now().UTC()
+		_, err := s.repo.UpdateLeasedJob(job, s.instanceID)
+		return err
+	}
+
+	log.Printf("JOB_CLAIMED: job=%s instance=%s results=%d", job.ID, s.instanceID, len(previous))
+	_, err = s.run(job, &request, previous)
+	return err
+}
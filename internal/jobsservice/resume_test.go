@@ -0,0 +1,57 @@
+package jobsservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumeProbesOnlyUnprobedTargetsAndKeepsPreviousFailures(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := newFakeRepo()
+	svc := newTestService(t, repo)
+
+	request, err := json.Marshal(checkRequest(server.URL+"/a", server.URL+"/b", server.URL+"/c"))
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateJob(&entity.Job{ID: "job", Status: entity.JobStatusRunning, Request: request, CreatedAt: time.Now().UTC()}))
+	repo.addResult(entity.JobResult{ID: "a", JobID: "job", Url: server.URL + "/a", Status: entity.JobResultStatusFailed})
+	repo.addResult(entity.JobResult{ID: "c", JobID: "job", Url: server.URL + "/c", Status: entity.JobResultStatusFailed})
+
+	svc.claim()
+	job := waitFinished(t, repo, "job")
+
+	mu.Lock()
+	assert.Equal(t, []string{"/b"}, paths)
+	mu.Unlock()
+	assert.Len(t, repo.resultsOf("job"), 3)
+	// only one url failed in this run, the job failed because the earlier results count too
+	assert.Equal(t, entity.JobStatusFailed, job.Status)
+}
+
+func TestResumeFailsJobsWithoutStoredRequest(t *testing.T) {
+	repo := newFakeRepo()
+	svc := newTestService(t, repo)
+	require.NoError(t, repo.CreateJob(&entity.Job{ID: "job", Status: entity.JobStatusPending}))
+
+	svc.claim()
+
+	job, err := repo.GetJob("job")
+	require.NoError(t, err)
+	assert.Equal(t, entity.JobStatusFailed, job.Status)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
 	"github.com/alirezazahiri/gofetch-v2/pkg/jobsutils"
 )
 
@@ -15,18 +16,114 @@ func (s *Service) Retrieve(ctx context.Context, request *jobsdto.RetrieveRequest
 
 	results := make([]jobsdto.JobResultItem, len(job.JobResults))
 	for i, result := range job.JobResults {
-		results[i] = jobsdto.JobResultItem{
-			URL:       result.Url,
-			LatencyMs: result.LatencyMs,
-			Status:    jobsutils.MapJobResultStatusToString(result.Status),
-		}
+		results[i] = newJobResultItem(&result)
 	}
 
 	return &jobsdto.RetrieveResponse{
 		JobID:      job.ID,
 		DurationMs: job.DurationMs,
+		Metadata:   newJobMetadataItem(job.Metadata),
 		CreatedAt:  job.CreatedAt,
 		Results:    results,
 		Status:     jobsutils.MapJobStatusToString(job.Status),
 	}, nil
 }
+
+func newJobMetadataItem(metadata *entity.JobMetadata) *jobsdto.JobMetadataItem {
+	if metadata == nil {
+		return nil
+	}
+
+	item := &jobsdto.JobMetadataItem{}
+	for _, limit := range metadata.ConcurrencyLimits {
+		item.ConcurrencyLimits = append(item.ConcurrencyLimits, jobsdto.ConcurrencyLimitItem{
+			Host:  limit.Host,
+			Limit: limit.Limit,
+			AtMs:  limit.AtMs,
+		})
+	}
+	return item
+}
+
+func newJobResultItem(result *entity.JobResult) jobsdto.JobResultItem {
+	item := jobsdto.JobResultItem{
+		URL:            result.Url,
+		NormalizedURL:  result.NormalizedUrl,
+		Scheme:         result.Scheme,
+		LatencyMs:      result.LatencyMs,
+		Status:         jobsutils.MapJobResultStatusToString(result.Status),
+		StatusCode:     result.StatusCode,
+		Proto:          result.Proto,
+		BytesRead:      result.BytesRead,
+		FinalURL:       result.FinalUrl,
+		Error:          result.Error,
+		HttpsDowngrade: result.HttpsDowngrade,
+		Proxy:          result.Proxy,
+		Timings: jobsdto.TimingsItem{
+			DnsMs:      result.Timings.DnsMs,
+			ConnectMs:  result.Timings.ConnectMs,
+			TlsMs:      result.Timings.TlsMs,
+			TtfbMs:     result.Timings.TtfbMs,
+			TransferMs: result.Timings.TransferMs,
+		},
+		AttemptCount:      result.AttemptCount,
+		AssertionFailures: result.AssertionFailures,
+	}
+
+	for _, attempt := range result.Attempts {
+		item.Attempts = append(item.Attempts, jobsdto.AttemptItem{
+			Attempt:    attempt.Attempt,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			LatencyMs:  attempt.LatencyMs,
+			BackoffMs:  attempt.BackoffMs,
+		})
+	}
+
+	for _, hop := range result.Redirects {
+		item.Redirects = append(item.Redirects, jobsdto.RedirectItem{
+			URL:        hop.Url,
+			StatusCode: hop.StatusCode,
+			LatencyMs:  hop.LatencyMs,
+		})
+	}
+
+	if result.Tcp != nil {
+		item.Tcp = &jobsdto.TcpItem{
+			RemoteAddr: result.Tcp.RemoteAddr,
+			Banner:     result.Tcp.Banner,
+		}
+	}
+
+	if result.Tls != nil {
+		item.Tls = &jobsdto.TlsItem{
+			Version:       result.Tls.Version,
+			ServerName:    result.Tls.ServerName,
+			HostnameValid: result.Tls.HostnameValid,
+			Verified:      result.Tls.Verified,
+			VerifyError:   result.Tls.VerifyError,
+			ExpiresSoon:   result.Tls.ExpiresSoon,
+		}
+		for _, cert := range result.Tls.Chain {
+			item.Tls.Chain = append(item.Tls.Chain, jobsdto.CertificateItem{
+				Subject:         cert.Subject,
+				Issuer:          cert.Issuer,
+				DNSNames:        cert.DNSNames,
+				NotBefore:       cert.NotBefore,
+				NotAfter:        cert.NotAfter,
+				DaysUntilExpiry: cert.DaysUntilExpiry,
+			})
+		}
+	}
+
+	if result.Dns != nil {
+		item.Dns = &jobsdto.DnsItem{
+			Resolver:   result.Dns.Resolver,
+			RecordType: result.Dns.RecordType,
+			RCode:      result.Dns.RCode,
+			Answers:    result.Dns.Answers,
+		}
+	}
+
+	return item
+}
@@ -0,0 +1,77 @@
+package jobsservice
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+)
+
+// probeSlot is the worker a probe runs on. It is given back while the probe
+// backs off between attempts, so the scheduler and the host of the probe are
+// not held up by a probe that is only waiting
+type probeSlot struct {
+	release func()
+	acquire func(ctx context.Context) (func(), error)
+}
+
+// done gives the worker back if the probe still holds it
+func (slot *probeSlot) done() {
+	if slot.release != nil {
+		slot.release()
+		slot.release = nil
+	}
+}
+
+// probeWithRetry probes url until it succeeds, fails in a way the retry policy
+// of spec does not cover or runs out of attempts. Every attempt gets its own
+// timeout and is recorded in the returned attempts. The first attempt runs on
+// slot, which is given back for every backoff and acquired again after it
+func (s *Service) probeWithRetry(ctx context.Context, url string, spec *pinger.Spec, timeout time.Duration, slot *probeSlot) (*pinger.Result, []entity.ProbeAttempt, error) {
+	policy := spec.Retry
+	var attempts []entity.ProbeAttempt
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		result, err := s.probers.Probe(pingCtx, url, spec)
+		cancel()
+
+		record := entity.ProbeAttempt{
+			Attempt:   attempt,
+			LatencyMs: durationToMs(time.Since(start)),
+		}
+		if result != nil && result.HTTP != nil {
+			record.StatusCode = result.HTTP.StatusCode
+		}
+		if err != nil {
+			record.Error = pinger.NormalizeError(err)
+		}
+		attempts = append(attempts, record)
+
+		if attempt >= policy.Attempts() || !policy.Retryable(result, err) {
+			return result, attempts, err
+		}
+
+		backoff := policy.Backoff(attempt)
+		attempts[len(attempts)-1].BackoffMs = durationToMs(backoff)
+		log.Printf("PING_RETRY: url=%s attempt=%d backoff=%s error=%v", url, attempt, backoff, err)
+
+		slot.done()
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, attempts, err
+		case <-timer.C:
+		}
+
+		release, acquireErr := slot.acquire(ctx)
+		if acquireErr != nil {
+			return result, attempts, err
+		}
+		slot.release = release
+	}
+}
@@ -0,0 +1,68 @@
+package jobsservice
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeGivesItsWorkerBackWhileBackingOff(t *testing.T) {
+	attempted := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempted <- struct{}{}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	svc := newTestService(t, newFakeRepo())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	probeScheduler := scheduler.New(scheduler.Config{Workers: 1})
+	go probeScheduler.Run(ctx)
+
+	retrying, err := probeScheduler.Submit(scheduler.Job{Size: 1})
+	require.NoError(t, err)
+	other, err := probeScheduler.Submit(scheduler.Job{Size: 1})
+	require.NoError(t, err)
+
+	release, err := retrying.Acquire(ctx, "retrying")
+	require.NoError(t, err)
+	slot := &probeSlot{
+		release: release,
+		acquire: func(ctx context.Context) (func(), error) { return retrying.Acquire(ctx, "retrying") },
+	}
+	spec := &pinger.Spec{Retry: &pinger.RetryPolicy{
+		MaxAttempts: 2,
+		BackoffBase: 500 * time.Millisecond,
+		BackoffCap:  500 * time.Millisecond,
+		RetryStatus: []pinger.StatusRange{{Min: 500, Max: 599}},
+	}}
+
+	probed := make(chan []int, 1)
+	go func() {
+		defer slot.done()
+		_, attempts, _ := svc.probeWithRetry(ctx, server.URL, spec, time.Second, slot)
+		statuses := make([]int, 0, len(attempts))
+		for _, attempt := range attempts {
+			statuses = append(statuses, attempt.StatusCode)
+		}
+		probed <- statuses
+	}()
+	<-attempted
+
+	// the only worker is free while the probe waits for its second attempt
+	waitCtx, waitCancel := context.WithTimeout(ctx, 250*time.Millisecond)
+	defer waitCancel()
+	otherRelease, err := other.Acquire(waitCtx, "other")
+	require.NoError(t, err)
+	otherRelease()
+
+	assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}, <-probed)
+}
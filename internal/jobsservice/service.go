@@ -1,15 +1,66 @@
 package jobsservice
 
 import (
-	"github.com/alirezazahiri/gofetch-v2/internal/repository/postgresql/jobsrepo"
+	"context"
+	"os"
+	"sync"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
+	"github.com/alirezazahiri/gofetch-v2/pkg/uuid"
 )
 
 type Service struct {
-	repo *jobsrepo.Repository
+	mu sync.Mutex
+	// running holds the cancel functions of the jobs this instance runs
+	running map[string]context.CancelFunc
+
+	repo    Repository
+	probers *pinger.Registry
+	// hostLimiter is shared by every job so the global per-host limits hold across jobs
+	hostLimiter *hostlimit.Limiter
+	// scheduler runs the probes of every job on one shared set of workers
+	scheduler *scheduler.Scheduler
+	cfg       *Config
+	// instanceID owns the leases of the jobs this instance runs
+	instanceID string
+}
+
+// SupportsScheme reports whether urls with the given scheme can be probed
+func (s *Service) SupportsScheme(scheme string) bool {
+	return s.probers.Supports(scheme)
+}
+
+// CheckTarget reports whether the egress policy refuses url before it is probed
+func (s *Service) CheckTarget(url string) error {
+	return s.probers.CheckTarget(url)
 }
 
-func New(repo *jobsrepo.Repository) *Service {
+// CheckResolver reports whether the egress policy refuses a resolver requested by a DNS probe
+func (s *Service) CheckResolver(resolver string) error {
+	return s.probers.CheckResolver(resolver)
+}
+
+// HasTLSProfile reports whether a check request may reference the named TLS profile
+func (s *Service) HasTLSProfile(name string) bool {
+	return s.probers.HasTLSProfile(name)
+}
+
+func New(repo Repository, probers *pinger.Registry, hostLimiter *hostlimit.Limiter, scheduler *scheduler.Scheduler, cfg *Config) *Service {
+	instanceID := cfg.InstanceID
+	if instanceID == "" {
+		hostname, _ := os.Hostname()
+		instanceID = hostname + "-" + uuid.New()[:8]
+	}
+
 	return &Service{
-		repo: repo,
+		repo:        repo,
+		probers:     probers,
+		hostLimiter: hostLimiter,
+		scheduler:   scheduler,
+		cfg:         cfg,
+		instanceID:  instanceID,
+		running:     make(map[string]context.CancelFunc),
 	}
 }
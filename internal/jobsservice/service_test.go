@@ -0,0 +1,266 @@
+package jobsservice
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeRepo keeps jobs in memory the way jobsrepo keeps them in postgres
+type fakeRepo struct {
+	mu      sync.Mutex
+	jobs    map[string]entity.Job
+	results []entity.JobResult
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{jobs: make(map[string]entity.Job)}
+}
+
+func (r *fakeRepo) CreateJob(job *entity.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+// UpdateLeasedJob leaves the cancel flag and the lease alone like the real one
+func (r *fakeRepo) UpdateLeasedJob(job *entity.Job, owner string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.jobs[job.ID]
+	if !holds(stored, owner) {
+		return false, nil
+	}
+	updated := *job
+	updated.CancelRequested = stored.CancelRequested
+	updated.LeaseOwner = stored.LeaseOwner
+	updated.LeaseExpiresAt = stored.LeaseExpiresAt
+	updated.JobResults = nil
+	r.jobs[job.ID] = updated
+	return true, nil
+}
+
+// holds reports whether owner has a live lease on job
+func holds(job entity.Job, owner string) bool {
+	return job.LeaseOwner == owner && job.LeaseExpiresAt != nil && job.LeaseExpiresAt.After(time.Now().UTC())
+}
+
+func (r *fakeRepo) DeleteJob(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.jobs, id)
+	return nil
+}
+
+func (r *fakeRepo) GetJob(id string) (*entity.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &job, nil
+}
+
+func (r *fakeRepo) GetJobWithResults(jobID string) (*entity.Job, error) {
+	job, err := r.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.JobResults = r.resultsOf(jobID)
+	return job, nil
+}
+
+func (r *fakeRepo) CreateLeasedJobResult(jobResult *entity.JobResult, owner string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !holds(r.jobs[jobResult.JobID], owner) {
+		return false, nil
+	}
+	r.results = append(r.results, *jobResult)
+	return true, nil
+}
+
+// addResult stores a result left by an earlier run
+func (r *fakeRepo) addResult(jobResult entity.JobResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, jobResult)
+}
+
+// takeOver leases id to another instance the way its claim would
+func (r *fakeRepo) takeOver(id, owner string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := r.jobs[id]
+	expiresAt := time.Now().UTC().Add(time.Minute)
+	job.LeaseOwner = owner
+	job.LeaseExpiresAt = &expiresAt
+	r.jobs[id] = job
+}
+
+func (r *fakeRepo) RequestJobCancel(id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok || job.IsFinished() {
+		return false, nil
+	}
+	job.CancelRequested = true
+	r.jobs[id] = job
+	return true, nil
+}
+
+func (r *fakeRepo) IsJobCancelRequested(id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.jobs[id].CancelRequested, nil
+}
+
+func (r *fakeRepo) ClaimJobs(owner string, ttl time.Duration, limit int) ([]entity.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var claimed []entity.Job
+	now := time.Now().UTC()
+	for id, job := range r.jobs {
+		if len(claimed) == limit {
+			break
+		}
+		if job.IsFinished() {
+			continue
+		}
+		if job.LeaseOwner != "" && job.LeaseExpiresAt != nil && job.LeaseExpiresAt.After(now) {
+			continue
+		}
+		expiresAt := now.Add(ttl)
+		job.LeaseOwner = owner
+		job.LeaseExpiresAt = &expiresAt
+		r.jobs[id] = job
+		claimed = append(claimed, job)
+	}
+	return claimed, nil
+}
+
+func (r *fakeRepo) RenewJobLease(id, owner string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok || job.LeaseOwner != owner {
+		return false, nil
+	}
+	expiresAt := time.Now().UTC().Add(ttl)
+	job.LeaseExpiresAt = &expiresAt
+	r.jobs[id] = job
+	return true, nil
+}
+
+func (r *fakeRepo) ReleaseJobLease(id, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok || job.LeaseOwner != owner {
+		return nil
+	}
+	job.LeaseOwner = ""
+	job.LeaseExpiresAt = nil
+	r.jobs[id] = job
+	return nil
+}
+
+// flagCancel cancels id the way another instance would, through the database only
+func (r *fakeRepo) flagCancel(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job := r.jobs[id]
+	job.CancelRequested = true
+	r.jobs[id] = job
+}
+
+func (r *fakeRepo) resultsOf(jobID string) []entity.JobResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var results []entity.JobResult
+	for _, result := range r.results {
+		if result.JobID == jobID {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func newTestService(t *testing.T, repo Repository) *Service {
+	t.Helper()
+
+	probers, err := pinger.NewDefaultRegistry(&pinger.Config{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	probeScheduler := scheduler.New(scheduler.Config{Workers: 4})
+	go probeScheduler.Run(ctx)
+
+	return New(repo, probers, hostlimit.New(hostlimit.Limits{}), probeScheduler, &Config{
+		MaxConcurrencyPerJob: 4,
+		CancelPollIntervalMs: 10,
+		Role:                 RoleAll,
+		InstanceID:           "test-instance",
+		LeaseTTLMs:           30000,
+		HeartbeatIntervalMs:  10000,
+	})
+}
+
+// startHangingServer answers nothing until the probe gives up and reports
+// every request it got on started
+func startHangingServer(t *testing.T) (*httptest.Server, <-chan struct{}) {
+	started := make(chan struct{}, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server, started
+}
+
+// waitFinished waits for the job to reach a final status and returns it
+func waitFinished(t *testing.T, repo *fakeRepo, id string) *entity.Job {
+	t.Helper()
+
+	var job *entity.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = repo.GetJob(id)
+		return err == nil && job.IsFinished()
+	}, 5*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func resultStatuses(results []entity.JobResult) []entity.JobResultStatus {
+	statuses := make([]entity.JobResultStatus, 0, len(results))
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+	}
+	slices.Sort(statuses)
+	return statuses
+}
@@ -0,0 +1,179 @@
+package jobsservice
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/urlnorm"
+)
+
+// target is one url of a job, as submitted and in the form it is probed under
+type target struct {
+	url        string
+	normalized string
+}
+
+func targetHost(t target) string {
+	return hostlimit.Key(t.normalized)
+}
+
+// checkTargets reports the first url of request that cannot be normalized
+func checkTargets(request *jobsdto.CheckRequest) error {
+	if !request.Normalize {
+		return nil
+	}
+	for _, url := range request.Urls {
+		if _, err := urlnorm.Normalize(url, urlnorm.DefaultScheme); err != nil {
+			return fmt.Errorf("url %s: %w", url, err)
+		}
+	}
+	return nil
+}
+
+// newTargets yields the urls of request one at a time, normalized when asked
+// to and without repeated targets, keeping the first occurrence. Urls that
+// cannot be normalized are skipped, checkTargets rejects them up front
+func newTargets(request *jobsdto.CheckRequest) iter.Seq[target] {
+	return func(yield func(target) bool) {
+		seen := make(map[string]bool)
+
+		for _, url := range request.Urls {
+			normalized := url
+			if request.Normalize {
+				var err error
+				normalized, err = urlnorm.Normalize(url, urlnorm.DefaultScheme)
+				if err != nil {
+					continue
+				}
+			}
+
+			if request.Deduplicate {
+				if seen[normalized] {
+					continue
+				}
+				seen[normalized] = true
+			}
+
+			if !yield(target{url: url, normalized: normalized}) {
+				return
+			}
+		}
+	}
+}
+
+// remainingTargets drops the targets that already have one of previous,
+// a url submitted twice needs two results to be dropped twice
+func remainingTargets(targets iter.Seq[target], previous []entity.JobResult) iter.Seq[target] {
+	if len(previous) == 0 {
+		return targets
+	}
+
+	return func(yield func(target) bool) {
+		done := make(map[string]int, len(previous))
+		for _, result := range previous {
+			done[result.Url]++
+		}
+
+		for t := range targets {
+			if done[t.url] > 0 {
+				done[t.url]--
+				continue
+			}
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// targetWindow remembers the targets handed to the pool whose result was
+// not read yet, so the ones a cancelled job never finished can be recorded
+type targetWindow struct {
+	mu      sync.Mutex
+	targets map[int]target
+	pulled  int
+}
+
+// track yields targets, remembering each under the index the pool gives it
+func (w *targetWindow) track(targets iter.Seq[target]) iter.Seq[target] {
+	return func(yield func(target) bool) {
+		for t := range targets {
+			w.mu.Lock()
+			if w.targets == nil {
+				w.targets = make(map[int]target)
+			}
+			w.targets[w.pulled] = t
+			w.pulled++
+			w.mu.Unlock()
+
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// take returns the target with index and forgets it
+func (w *targetWindow) take(index int) target {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	t := w.targets[index]
+	delete(w.targets, index)
+	return t
+}
+
+// unfinished yields the targets taken but never finished, then held, the
+// ones read from targets but held back, then the ones of targets that were
+// not read at all
+func (w *targetWindow) unfinished(targets iter.Seq[target], held []target) iter.Seq[target] {
+	w.mu.Lock()
+	pulled := w.pulled
+	left := make([]int, 0, len(w.targets))
+	for index := range w.targets {
+		left = append(left, index)
+	}
+	slices.Sort(left)
+	taken := make([]target, 0, len(left))
+	for _, index := range left {
+		taken = append(taken, w.targets[index])
+	}
+	w.mu.Unlock()
+
+	return func(yield func(target) bool) {
+		for _, t := range slices.Concat(taken, held) {
+			if !yield(t) {
+				return
+			}
+		}
+
+		skipped := 0
+		for t := range targets {
+			if skipped < pulled+len(held) {
+				skipped++
+				continue
+			}
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// countFailedResults counts the results that did not get a healthy answer
+func countFailedResults(results []entity.JobResult) int {
+	count := 0
+	for _, result := range results {
+		switch result.Status {
+		case entity.JobResultStatusCompleted, entity.JobResultStatusDegraded:
+		default:
+			count++
+		}
+	}
+	return count
+}
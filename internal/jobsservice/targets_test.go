@@ -0,0 +1,68 @@
+package jobsservice
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/delivery/dto/jobsdto"
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func urlsOf(targets []target) []string {
+	urls := make([]string, 0, len(targets))
+	for _, t := range targets {
+		urls = append(urls, t.url)
+	}
+	return urls
+}
+
+func TestNewTargetsDeduplicatesNormalizedUrls(t *testing.T) {
+	request := &jobsdto.CheckRequest{
+		Urls:        []string{"example.com", "EXAMPLE.com", "example.org"},
+		Normalize:   true,
+		Deduplicate: true,
+	}
+
+	assert.NoError(t, checkTargets(request))
+	assert.Equal(t, []string{"example.com", "example.org"}, urlsOf(slices.Collect(newTargets(request))))
+}
+
+func TestRemainingTargetsSkipsUrlsWithResults(t *testing.T) {
+	request := &jobsdto.CheckRequest{Urls: []string{"http://a", "http://b", "http://a", "http://c"}}
+	previous := []entity.JobResult{{Url: "http://a"}, {Url: "http://c"}}
+
+	remaining := remainingTargets(newTargets(request), previous)
+
+	// a url submitted twice needs two results, the second http://a is still due
+	assert.Equal(t, []string{"http://b", "http://a"}, urlsOf(slices.Collect(remaining)))
+	assert.Equal(t, urlsOf(slices.Collect(remaining)), urlsOf(slices.Collect(remaining)), "the sequence can be read again")
+	assert.Len(t, slices.Collect(remainingTargets(newTargets(request), nil)), 4)
+}
+
+func TestTargetWindowYieldsUnfinishedTargets(t *testing.T) {
+	request := &jobsdto.CheckRequest{Urls: []string{"http://a", "http://b", "http://c", "http://d"}}
+	targets := newTargets(request)
+	window := &targetWindow{}
+
+	// the pool took a and b, finished a and never got to c and d
+	for taken := range window.track(targets) {
+		if taken.url == "http://b" {
+			break
+		}
+	}
+	window.take(0)
+
+	assert.Equal(t, []string{"http://b", "http://c", "http://d"}, urlsOf(slices.Collect(window.unfinished(targets, nil))))
+}
+
+func TestCountFailedResults(t *testing.T) {
+	results := []entity.JobResult{
+		{Status: entity.JobResultStatusCompleted},
+		{Status: entity.JobResultStatusDegraded},
+		{Status: entity.JobResultStatusTimeout},
+		{Status: entity.JobResultStatusFailed},
+	}
+
+	assert.Equal(t, 2, countFailedResults(results))
+}
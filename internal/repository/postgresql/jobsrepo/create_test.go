@@ -0,0 +1,28 @@
+package jobsrepo
+
+import (
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateJobResultStoresZeroAttempts(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	repo := New(db)
+
+	// a target cancelled before it was probed has no attempts
+	jobResult := &entity.JobResult{
+		ID:           "result",
+		JobID:        "job",
+		Url:          "https://example.com",
+		Status:       entity.JobResultStatusCancelled,
+		AttemptCount: 0,
+	}
+	require.NoError(t, repo.CreateJobResult(jobResult))
+
+	require.Len(t, recorder.statements, 1)
+	assert.Contains(t, recorder.statements[0], `"attempt_count"`)
+	assert.Equal(t, 0, jobResult.AttemptCount)
+}
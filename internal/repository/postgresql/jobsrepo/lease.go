@@ -0,0 +1,104 @@
+package jobsrepo
+
+import (
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var unfinishedJobStatuses = []entity.JobStatus{entity.JobStatusPending, entity.JobStatusRunning}
+
+// Leases are compared and extended on the clock of the database so instances
+// whose clocks drift apart still agree on when a lease runs out
+
+// claimableJobs selects up to limit unfinished jobs without a live lease,
+// oldest first, locking them and skipping rows a concurrent claim locked
+func claimableJobs(tx *gorm.DB, limit int) *gorm.DB {
+	return tx.Model(&entity.Job{}).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Select("id").
+		Where("status IN ?", unfinishedJobStatuses).
+		Where("(lease_owner = '' OR lease_expires_at IS NULL OR lease_expires_at <= now())").
+		Order("created_at").
+		Limit(limit)
+}
+
+// heldLease selects the job while owner holds a live lease on it
+func heldLease(tx *gorm.DB, id, owner string) *gorm.DB {
+	return tx.Model(&entity.Job{}).
+		Where("id = ? AND lease_owner = ? AND lease_expires_at > now()", id, owner)
+}
+
+// lockHeldLease is heldLease share locking the job, which keeps claims off it
+// but lets other writers holding the same lease through
+func lockHeldLease(tx *gorm.DB, id, owner string) *gorm.DB {
+	return heldLease(tx, id, owner).Clauses(clause.Locking{Strength: "SHARE"})
+}
+
+func leaseExpiry(ttl time.Duration) clause.Expr {
+	return gorm.Expr("now() + make_interval(secs => ?)", ttl.Seconds())
+}
+
+// ClaimJobs leases up to limit unfinished jobs to owner for ttl, oldest first.
+// Only jobs without a live lease are claimed and rows locked by a concurrent
+// claim are skipped, so every job goes to a single instance
+func (r *Repository) ClaimJobs(owner string, ttl time.Duration, limit int) ([]entity.Job, error) {
+	var jobs []entity.Job
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimableJobs(tx, limit).Find(&jobs).Error; err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		ids := make([]string, len(jobs))
+		for i, job := range jobs {
+			ids[i] = job.ID
+		}
+		return tx.Model(&entity.Job{}).Where("id IN ?", ids).Updates(map[string]any{
+			"lease_owner":      owner,
+			"lease_expires_at": leaseExpiry(ttl),
+		}).Error
+	})
+
+	return jobs, err
+}
+
+// RenewJobLease extends the lease owner holds on a job by ttl. It reports
+// false once the lease was taken over by another instance, a lease that ran
+// out but was not claimed yet is still renewed
+func (r *Repository) RenewJobLease(id, owner string, ttl time.Duration) (bool, error) {
+	result := r.db.Model(&entity.Job{}).
+		Where("id = ? AND lease_owner = ?", id, owner).
+		Update("lease_expires_at", leaseExpiry(ttl))
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReleaseJobLease drops the lease owner holds on a job
+func (r *Repository) ReleaseJobLease(id, owner string) error {
+	return r.db.Model(&entity.Job{}).
+		Where("id = ? AND lease_owner = ?", id, owner).
+		Updates(map[string]any{"lease_owner": "", "lease_expires_at": nil}).Error
+}
+
+// CreateLeasedJobResult stores jobResult only while owner still holds a
+// live lease on its job and reports whether it did. The job row is share
+// locked until the result is written, so a claim cannot take the job over
+// in between and the next owner never sees the result twice
+func (r *Repository) CreateLeasedJobResult(jobResult *entity.JobResult, owner string) (bool, error) {
+	held := false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		err := lockHeldLease(tx, jobResult.JobID, owner).Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		held = true
+		return tx.Create(jobResult).Error
+	})
+
+	return held, err
+}
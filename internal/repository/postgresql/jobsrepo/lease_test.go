@@ -0,0 +1,82 @@
+package jobsrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder keeps the statements gorm would have sent
+type sqlRecorder struct {
+	logger.Interface
+	statements []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.statements = append(r.statements, sql)
+}
+
+// newDryRunDB builds statements for postgres without connecting to one.
+// Writes skip their implicit transaction, beginning one would connect
+func newDryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 recorder,
+	})
+	require.NoError(t, err)
+	return db, recorder
+}
+
+func TestClaimableJobsSkipLiveLeasesAndLockedRows(t *testing.T) {
+	db, _ := newDryRunDB(t)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var jobs []entity.Job
+		return claimableJobs(tx, 4).Find(&jobs)
+	})
+
+	assert.Equal(t, `SELECT "id" FROM "jobs" WHERE status IN (0,1) `+
+		`AND ((lease_owner = '' OR lease_expires_at IS NULL OR lease_expires_at <= now())) `+
+		`ORDER BY created_at LIMIT 4 FOR UPDATE SKIP LOCKED`, sql)
+}
+
+func TestLeasedResultsNeedALiveLeaseOfTheOwner(t *testing.T) {
+	db, _ := newDryRunDB(t)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var ids []string
+		return lockHeldLease(tx, "job", "worker-1").Pluck("id", &ids)
+	})
+
+	assert.Equal(t, `SELECT "id" FROM "jobs" WHERE id = 'job' AND lease_owner = 'worker-1' `+
+		`AND lease_expires_at > now() FOR SHARE`, sql)
+}
+
+func TestRenewAndReleaseOnlyTouchTheOwnersLease(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	repo := New(db)
+
+	_, err := repo.RenewJobLease("job", "worker-1", 30*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, repo.ReleaseJobLease("job", "worker-1"))
+
+	require.Len(t, recorder.statements, 2)
+	assert.Contains(t, recorder.statements[0], `SET "lease_expires_at"=now() + make_interval(secs => 30)`)
+	assert.Contains(t, recorder.statements[0], `WHERE id = 'job' AND lease_owner = 'worker-1'`)
+	assert.Contains(t, recorder.statements[1], `"lease_expires_at"=NULL`)
+	assert.Contains(t, recorder.statements[1], `"lease_owner"=''`)
+	assert.Contains(t, recorder.statements[1], `WHERE id = 'job' AND lease_owner = 'worker-1'`)
+}
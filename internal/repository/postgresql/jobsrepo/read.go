@@ -12,6 +12,13 @@ func (r *Repository) GetJobWithResults(jobID string) (*entity.Job, error) {
 	return job, r.db.Preload("JobResults").Where("id = ?", jobID).First(job).Error
 }
 
+// IsJobCancelRequested reports whether a job was flagged for cancellation
+func (r *Repository) IsJobCancelRequested(id string) (bool, error) {
+	var requested bool
+	err := r.db.Model(&entity.Job{}).Select("cancel_requested").Where("id = ?", id).Scan(&requested).Error
+	return requested, err
+}
+
 func (r *Repository) GetJobResult(id string) (*entity.JobResult, error) {
 	jobResult := &entity.JobResult{}
 	return jobResult, r.db.Where("id = ?", id).First(jobResult).Error
@@ -2,6 +2,24 @@ package jobsrepo
 
 import "github.com/alirezazahiri/gofetch-v2/internal/entity"
 
-func (r *Repository) UpdateJob(job *entity.Job) error {
-	return r.db.Save(job).Error
+// UpdateLeasedJob saves job only while owner still holds a live lease on it
+// and reports whether it did, so a run that lost its job cannot overwrite
+// what the new owner wrote. CancelRequested is left out so a cancel request
+// stored by another instance is never overwritten, and the lease which is
+// only moved by the lease methods
+func (r *Repository) UpdateLeasedJob(job *entity.Job, owner string) (bool, error) {
+	result := heldLease(r.db, job.ID, owner).
+		Select("*").
+		Omit("ID", "CreatedAt", "CancelRequested", "LeaseOwner", "LeaseExpiresAt", "JobResults").
+		Updates(job)
+	return result.RowsAffected > 0, result.Error
+}
+
+// RequestJobCancel flags a job that has not finished yet for cancellation and
+// reports whether it was still running
+func (r *Repository) RequestJobCancel(id string) (bool, error) {
+	result := r.db.Model(&entity.Job{}).
+		Where("id = ? AND status IN ?", id, unfinishedJobStatuses).
+		Update("cancel_requested", true)
+	return result.RowsAffected > 0, result.Error
 }
@@ -0,0 +1,32 @@
+package jobsrepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateLeasedJobNeedsALiveLeaseOfTheOwner(t *testing.T) {
+	db, recorder := newDryRunDB(t)
+	repo := New(db)
+
+	_, err := repo.UpdateLeasedJob(&entity.Job{
+		ID:         "job",
+		Status:     entity.JobStatusCompleted,
+		DurationMs: 1200,
+		UpdatedAt:  time.Now().UTC(),
+	}, "worker-1")
+	require.NoError(t, err)
+
+	require.Len(t, recorder.statements, 1)
+	sql := recorder.statements[0]
+	assert.Contains(t, sql, `"status"=2`)
+	assert.Contains(t, sql, `"duration_ms"=1200`)
+	assert.Contains(t, sql, `WHERE id = 'job' AND lease_owner = 'worker-1' AND lease_expires_at > now()`)
+	assert.NotContains(t, sql, "cancel_requested")
+	assert.NotContains(t, sql, `"lease_owner"=`)
+	assert.NotContains(t, sql, "ON CONFLICT", "a lost lease must not fall back to an upsert")
+}
@@ -83,6 +83,16 @@ func Conflict(c *gin.Context, message string, details interface{}) {
 	ErrorResponse(c, http.StatusConflict, "CONFLICT", message, details)
 }
 
+// PayloadTooLarge sends a 413 Payload Too Large error
+func PayloadTooLarge(c *gin.Context, message string, details interface{}) {
+	ErrorResponse(c, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", message, details)
+}
+
+// TooManyRequests sends a 429 Too Many Requests error
+func TooManyRequests(c *gin.Context, message string, details interface{}) {
+	ErrorResponse(c, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", message, details)
+}
+
 // InternalServerError sends a 500 Internal Server Error
 func InternalServerError(c *gin.Context, message string, details interface{}) {
 	ErrorResponse(c, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", message, details)
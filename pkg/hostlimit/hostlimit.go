@@ -0,0 +1,187 @@
+// Package hostlimit caps how hard a single host is hit: how many requests may
+// be in flight at once and how many may start per second
+package hostlimit
+
+import (
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepThreshold is the number of tracked hosts above which idle hosts are forgotten
+const sweepThreshold = 1024
+
+// Limits configures a Limiter, zero values mean unlimited
+type Limits struct {
+	MaxConcurrent     int     `koanf:"max_concurrent"`
+	RequestsPerSecond float64 `koanf:"requests_per_second"`
+	Burst             int     `koanf:"burst"`
+}
+
+// Unlimited reports whether the limits never throttle anything
+func (l Limits) Unlimited() bool {
+	return l.MaxConcurrent <= 0 && l.RequestsPerSecond <= 0
+}
+
+func (l Limits) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.RequestsPerSecond))
+}
+
+type hostState struct {
+	active int
+	tokens float64
+	last   time.Time
+}
+
+// Limiter enforces Limits separately for every host. It is safe for
+// concurrent use and meant to be shared by everything probing the same hosts
+type Limiter struct {
+	limits Limits
+	now    func() time.Time
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// New creates a Limiter enforcing limits on every host
+func New(limits Limits) *Limiter {
+	return &Limiter{
+		limits: limits,
+		now:    time.Now,
+		hosts:  make(map[string]*hostState),
+	}
+}
+
+// Reserve takes a slot for host without blocking. When ok is false the host
+// is throttled: wait is how long until its rate allows another request, or 0
+// when it is at its concurrency cap and a slot has to be released first.
+// The returned release must be called once the request is done
+func (l *Limiter) Reserve(host string) (release func(), wait time.Duration, ok bool) {
+	r, wait, ok := l.reserve(host)
+	if !ok {
+		return nil, wait, false
+	}
+	return r.release, 0, true
+}
+
+type reservation struct {
+	limiter *Limiter
+	host    string
+	once    sync.Once
+}
+
+func (l *Limiter) reserve(host string) (*reservation, time.Duration, bool) {
+	if l == nil || l.limits.Unlimited() {
+		return &reservation{}, 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	state, ok := l.hosts[host]
+	if !ok {
+		if len(l.hosts) >= sweepThreshold {
+			l.sweep(now)
+		}
+		state = &hostState{tokens: l.limits.burst(), last: now}
+		l.hosts[host] = state
+	}
+
+	if l.limits.MaxConcurrent > 0 && state.active >= l.limits.MaxConcurrent {
+		return nil, 0, false
+	}
+
+	if rate := l.limits.RequestsPerSecond; rate > 0 {
+		state.tokens = math.Min(l.limits.burst(), state.tokens+now.Sub(state.last).Seconds()*rate)
+		state.last = now
+		if state.tokens < 1 {
+			wait := time.Duration((1 - state.tokens) / rate * float64(time.Second))
+			return nil, max(wait, time.Millisecond), false
+		}
+		state.tokens--
+	}
+
+	state.active++
+	return &reservation{limiter: l, host: host}, 0, true
+}
+
+// release frees the concurrency slot of the reservation
+func (r *reservation) release() {
+	if r.limiter == nil {
+		return
+	}
+	r.once.Do(func() {
+		r.limiter.mu.Lock()
+		defer r.limiter.mu.Unlock()
+		if state, ok := r.limiter.hosts[r.host]; ok && state.active > 0 {
+			state.active--
+		}
+	})
+}
+
+// cancel frees the slot and gives back the rate token of a reservation that was never used
+func (r *reservation) cancel() {
+	if r.limiter == nil {
+		return
+	}
+	r.release()
+	r.limiter.mu.Lock()
+	defer r.limiter.mu.Unlock()
+	if state, ok := r.limiter.hosts[r.host]; ok && r.limiter.limits.RequestsPerSecond > 0 {
+		state.tokens = math.Min(r.limiter.limits.burst(), state.tokens+1)
+	}
+}
+
+// sweep forgets hosts with nothing in flight and a full bucket, callers hold mu
+func (l *Limiter) sweep(now time.Time) {
+	for host, state := range l.hosts {
+		if state.active > 0 {
+			continue
+		}
+		if l.limits.RequestsPerSecond > 0 && state.tokens+now.Sub(state.last).Seconds()*l.limits.RequestsPerSecond < l.limits.burst() {
+			continue
+		}
+		delete(l.hosts, host)
+	}
+}
+
+// Group enforces several limiters at once, a host is only let through
+// when every limiter of the group has room for it. Nil limiters are skipped
+type Group []*Limiter
+
+// Reserve behaves like Limiter.Reserve across every limiter of the group
+func (g Group) Reserve(host string) (release func(), wait time.Duration, ok bool) {
+	taken := make([]*reservation, 0, len(g))
+	for _, limiter := range g {
+		r, wait, ok := limiter.reserve(host)
+		if !ok {
+			for _, t := range taken {
+				t.cancel()
+			}
+			return nil, wait, false
+		}
+		taken = append(taken, r)
+	}
+
+	return func() {
+		for _, t := range taken {
+			t.release()
+		}
+	}, 0, true
+}
+
+// Key returns the host a url is limited under, targets that do not parse
+// are limited on their own
+func Key(target string) string {
+	u, err := url.Parse(target)
+	if err != nil || u.Hostname() == "" {
+		return target
+	}
+	return strings.ToLower(u.Hostname())
+}
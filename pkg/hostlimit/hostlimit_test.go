@@ -0,0 +1,88 @@
+package hostlimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterCapsConcurrencyPerHost(t *testing.T) {
+	limiter := hostlimit.New(hostlimit.Limits{MaxConcurrent: 2})
+
+	first, _, ok := limiter.Reserve("a.example")
+	assert.True(t, ok)
+	_, _, ok = limiter.Reserve("a.example")
+	assert.True(t, ok)
+
+	_, wait, ok := limiter.Reserve("a.example")
+	assert.False(t, ok)
+	assert.Zero(t, wait)
+
+	_, _, ok = limiter.Reserve("b.example")
+	assert.True(t, ok, "other hosts keep their own slots")
+
+	first()
+	first()
+	_, _, ok = limiter.Reserve("a.example")
+	assert.True(t, ok, "releasing twice frees a single slot")
+	_, _, ok = limiter.Reserve("a.example")
+	assert.False(t, ok)
+}
+
+func TestLimiterRateLimitsPerHost(t *testing.T) {
+	limiter := hostlimit.New(hostlimit.Limits{RequestsPerSecond: 10, Burst: 2})
+
+	for range 2 {
+		_, _, ok := limiter.Reserve("a.example")
+		assert.True(t, ok)
+	}
+
+	_, wait, ok := limiter.Reserve("a.example")
+	assert.False(t, ok)
+	assert.Greater(t, wait, time.Duration(0))
+	assert.LessOrEqual(t, wait, 100*time.Millisecond)
+
+	time.Sleep(wait)
+	_, _, ok = limiter.Reserve("a.example")
+	assert.True(t, ok)
+}
+
+func TestGroupGivesBackPartialReservations(t *testing.T) {
+	global := hostlimit.New(hostlimit.Limits{RequestsPerSecond: 1, Burst: 1})
+	job := hostlimit.New(hostlimit.Limits{MaxConcurrent: 1})
+	group := hostlimit.Group{global, nil, job}
+
+	// the job limiter is full, so the global token must not be spent
+	jobRelease, _, ok := job.Reserve("a.example")
+	assert.True(t, ok)
+	_, _, ok = group.Reserve("a.example")
+	assert.False(t, ok)
+
+	jobRelease()
+	release, _, ok := group.Reserve("a.example")
+	assert.True(t, ok)
+	release()
+
+	_, wait, ok := group.Reserve("a.example")
+	assert.False(t, ok)
+	assert.Greater(t, wait, time.Duration(0))
+}
+
+func TestUnlimited(t *testing.T) {
+	var none *hostlimit.Limiter
+	release, _, ok := none.Reserve("a.example")
+	assert.True(t, ok)
+	release()
+
+	release, _, ok = hostlimit.New(hostlimit.Limits{}).Reserve("a.example")
+	assert.True(t, ok)
+	release()
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "example.com", hostlimit.Key("https://Example.com:8443/path"))
+	assert.Equal(t, "example.com", hostlimit.Key("tcp://example.com:22"))
+	assert.Equal(t, "not a url", hostlimit.Key("not a url"))
+}
@@ -1,10 +1,6 @@
 package jobsutils
 
-import (
-	"runtime"
-
-	"github.com/alirezazahiri/gofetch-v2/internal/entity"
-)
+import "github.com/alirezazahiri/gofetch-v2/internal/entity"
 
 func MapJobStatusToString(status entity.JobStatus) string {
 	switch status {
@@ -16,6 +12,8 @@ func MapJobStatusToString(status entity.JobStatus) string {
 		return "completed"
 	case entity.JobStatusFailed:
 		return "failed"
+	case entity.JobStatusCancelled:
+		return "cancelled"
 	}
 	return "unknown"
 }
@@ -28,16 +26,36 @@ func MapJobResultStatusToString(status entity.JobResultStatus) string {
 		return "failed"
 	case entity.JobResultStatusTimeout:
 		return "timeout"
+	case entity.JobResultStatusRefused:
+		return "refused"
+	case entity.JobResultStatusReset:
+		return "reset"
+	case entity.JobResultStatusDegraded:
+		return "degraded"
+	case entity.JobResultStatusNXDomain:
+		return "nxdomain"
+	case entity.JobResultStatusServFail:
+		return "servfail"
+	case entity.JobResultStatusMismatch:
+		return "mismatch"
+	case entity.JobResultStatusBlocked:
+		return "blocked"
+	case entity.JobResultStatusCancelled:
+		return "cancelled"
 	}
 	return "unknown"
 }
 
-func NormalizeNumberOfWorkers(numberOfWorkers int) int {
+// NormalizeNumberOfWorkers keeps the requested number of workers between 1
+// and each of the given ceilings, ceilings of 0 or less are ignored
+func NormalizeNumberOfWorkers(numberOfWorkers int, ceilings ...int) int {
+	for _, ceiling := range ceilings {
+		if ceiling > 0 && numberOfWorkers > ceiling {
+			numberOfWorkers = ceiling
+		}
+	}
 	if numberOfWorkers < 1 {
 		return 1
 	}
-	if numberOfWorkers > runtime.NumCPU() {
-		return runtime.NumCPU()
-	}
 	return numberOfWorkers
-}
\ No newline at end of file
+}
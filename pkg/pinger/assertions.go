@@ -0,0 +1,103 @@
+package pinger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+var ErrAssertionFailed = errors.New("assertion failed")
+
+// defaultAssertionBodyLimit caps how much of a body is buffered for assertions
+// when the spec does not set MaxBodyBytes
+const defaultAssertionBodyLimit = 10 << 20
+
+type JSONPathAssertion struct {
+	Path   JSONPath
+	Equals any
+}
+
+// Assertions are checks evaluated against the response body
+type Assertions struct {
+	BodyContains    []string
+	BodyNotContains []string
+	BodyMatches     []*regexp.Regexp
+	JSONPath        []JSONPathAssertion
+	MaxBodyBytes    int64
+}
+
+func (a *Assertions) bodyLimit() int64 {
+	if a.MaxBodyBytes > 0 {
+		return a.MaxBodyBytes
+	}
+	return defaultAssertionBodyLimit
+}
+
+// evaluate returns a description of every assertion the body does not satisfy.
+// truncated is set when the body was larger than bodyLimit
+func (a *Assertions) evaluate(body []byte, truncated bool) []string {
+	var failures []string
+
+	if truncated {
+		failures = append(failures, fmt.Sprintf("body exceeds %d bytes", a.bodyLimit()))
+		if a.MaxBodyBytes > 0 {
+			return failures
+		}
+	}
+
+	for _, value := range a.BodyContains {
+		if !bytes.Contains(body, []byte(value)) {
+			failures = append(failures, fmt.Sprintf("body does not contain %q", value))
+		}
+	}
+
+	for _, value := range a.BodyNotContains {
+		if bytes.Contains(body, []byte(value)) {
+			failures = append(failures, fmt.Sprintf("body contains %q", value))
+		}
+	}
+
+	for _, pattern := range a.BodyMatches {
+		if !pattern.Match(body) {
+			failures = append(failures, fmt.Sprintf("body does not match %q", pattern.String()))
+		}
+	}
+
+	if len(a.JSONPath) > 0 {
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return append(failures, "body is not valid json: "+err.Error())
+		}
+
+		for _, assertion := range a.JSONPath {
+			actual, ok := assertion.Path.Lookup(doc)
+			if !ok {
+				failures = append(failures, fmt.Sprintf("%s not found", assertion.Path))
+				continue
+			}
+			if !reflect.DeepEqual(actual, normalizeJSONValue(assertion.Equals)) {
+				failures = append(failures, fmt.Sprintf("%s is %v, expected %v", assertion.Path, actual, assertion.Equals))
+			}
+		}
+	}
+
+	return failures
+}
+
+// normalizeJSONValue round-trips value through encoding/json so that it
+// compares equal to values decoded from a response body (e.g. int -> float64)
+func normalizeJSONValue(value any) any {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var normalized any
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
@@ -0,0 +1,69 @@
+package pinger_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPProbeEvaluatesAssertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"degraded","checks":[{"name":"db","latency":12}]}`))
+	}))
+	defer server.Close()
+
+	status, _ := pinger.ParseJSONPath("$.status")
+	latency, _ := pinger.ParseJSONPath("$.checks[0].latency")
+
+	result, err := probe(server.URL, &pinger.Spec{
+		Assertions: &pinger.Assertions{
+			BodyContains:    []string{`"checks"`},
+			BodyNotContains: []string{"degraded"},
+			BodyMatches:     []*regexp.Regexp{regexp.MustCompile(`"name":"db"`)},
+			JSONPath: []pinger.JSONPathAssertion{
+				{Path: status, Equals: "ok"},
+				{Path: latency, Equals: 12},
+			},
+		},
+	})
+
+	assert.ErrorIs(t, err, pinger.ErrAssertionFailed)
+	assert.Equal(t, []string{
+		`body contains "degraded"`,
+		`$.status is degraded, expected ok`,
+	}, result.HTTP.AssertionFailures)
+}
+
+func TestHTTPProbeEnforcesMaxBodyBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(make([]byte, 64))
+	}))
+	defer server.Close()
+
+	result, err := probe(server.URL, &pinger.Spec{
+		Assertions: &pinger.Assertions{MaxBodyBytes: 16},
+	})
+
+	assert.ErrorIs(t, err, pinger.ErrAssertionFailed)
+	assert.Equal(t, []string{"body exceeds 16 bytes"}, result.HTTP.AssertionFailures)
+}
+
+func TestParseJSONPath(t *testing.T) {
+	path, err := pinger.ParseJSONPath(`$.data["content-type"][1]`)
+	assert.NoError(t, err)
+
+	value, ok := path.Lookup(map[string]any{
+		"data": map[string]any{"content-type": []any{"a", "b"}},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "b", value)
+
+	_, err = pinger.ParseJSONPath("status")
+	assert.Error(t, err)
+	_, err = pinger.ParseJSONPath("$.a[")
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package pinger
+
+import "crypto/tls"
+
+// TransportConfig tunes the connections shared by every probe
+type TransportConfig struct {
+	MaxIdleConns          int  `koanf:"max_idle_conns"`
+	MaxIdleConnsPerHost   int  `koanf:"max_idle_conns_per_host"`
+	MaxConnsPerHost       int  `koanf:"max_conns_per_host"`
+	IdleConnTimeoutMs     int  `koanf:"idle_conn_timeout_ms"`
+	DialTimeoutMs         int  `koanf:"dial_timeout_ms"`
+	TLSHandshakeTimeoutMs int  `koanf:"tls_handshake_timeout_ms"`
+	KeepAliveMs           int  `koanf:"keep_alive_ms"`
+	DisableKeepAlives     bool `koanf:"disable_keep_alives"`
+}
+
+type Config struct {
+	// CertExpiryWarningDays marks a result degraded when the peer certificate
+	// expires within this many days, 0 disables the warning
+	CertExpiryWarningDays int `koanf:"cert_expiry_warning_days"`
+
+	// DNSResolver is the host:port dns probes query by default,
+	// empty uses the first nameserver of /etc/resolv.conf
+	DNSResolver string `koanf:"dns_resolver"`
+
+	Transport TransportConfig `koanf:"transport"`
+
+	// Proxy is the proxy of http probes that do not choose their own
+	Proxy ProxyConfig `koanf:"proxy"`
+
+	// Egress limits the addresses, schemes and ports probes may reach.
+	// Connections to a proxy are checked as well, so proxies on a denied
+	// range need to be listed in AllowCIDRs. A target sent through a proxy
+	// is resolved before the request and refused when any of its addresses is
+	Egress EgressConfig `koanf:"egress"`
+
+	// TLSProfiles are the TLS client setups a probe can select by name
+	TLSProfiles map[string]TLSProfile `koanf:"tls_profiles"`
+
+	// TLSClientConfig is the base TLS configuration of every probe,
+	// nil uses the system defaults
+	TLSClientConfig *tls.Config `koanf:"-"`
+}
@@ -0,0 +1,281 @@
+package pinger
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var (
+	ErrNXDomain       = errors.New("nxdomain")
+	ErrServFail       = errors.New("servfail")
+	ErrDNSMismatch    = errors.New("dns answers do not match expected values")
+	ErrDNSQueryFailed = errors.New("dns query failed")
+)
+
+// fallbackResolver is used when no resolver is configured and none can be read from resolv.conf
+const fallbackResolver = "127.0.0.1:53"
+
+var dnsRecordTypes = map[string]dnsmessage.Type{
+	"A":     dnsmessage.TypeA,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"CNAME": dnsmessage.TypeCNAME,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+}
+
+// IsSupportedDNSRecordType reports whether a DNS probe can query recordType
+func IsSupportedDNSRecordType(recordType string) bool {
+	_, ok := dnsRecordTypes[strings.ToUpper(recordType)]
+	return ok
+}
+
+// DNSSpec describes the query a DNS probe sends and the answers it expects.
+// Every Expected value has to appear in the answers for the probe to succeed
+type DNSSpec struct {
+	RecordType string
+	Resolver   string
+	Expected   []string
+}
+
+// DNSResult holds what was observed while resolving a dns target
+type DNSResult struct {
+	Resolver   string
+	RecordType string
+	RCode      string
+	Answers    []string
+}
+
+// DNSProber resolves dns://name targets through a configurable resolver.
+// The record type can also be given as a query parameter: dns://example.com?type=MX
+type DNSProber struct {
+	cfg    *Config
+	dialer *net.Dialer
+	// resolverDialer reaches the configured and system resolvers, which
+	// commonly live on loopback or private addresses the egress policy denies
+	resolverDialer *net.Dialer
+}
+
+func NewDNSProber(cfg *Config) *DNSProber {
+	return &DNSProber{
+		cfg:            cfg,
+		dialer:         newDialer(cfg),
+		resolverDialer: newUnguardedDialer(cfg),
+	}
+}
+
+func (p *DNSProber) Probe(ctx context.Context, target *url.URL, spec *Spec) (*Result, error) {
+	log.Printf("PINGING_DNS: target=%s", target.Host)
+
+	var dnsSpec DNSSpec
+	if spec != nil && spec.DNS != nil {
+		dnsSpec = *spec.DNS
+	}
+
+	recordType := strings.ToUpper(dnsSpec.RecordType)
+	if recordType == "" {
+		recordType = strings.ToUpper(target.Query().Get("type"))
+	}
+	if recordType == "" {
+		recordType = "A"
+	}
+	qtype, ok := dnsRecordTypes[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dns record type %q", recordType)
+	}
+
+	resolver := p.resolver(&dnsSpec)
+	dnsResult := &DNSResult{Resolver: resolver, RecordType: recordType}
+	result := &Result{DNS: dnsResult}
+
+	fqdn := target.Hostname()
+	if !strings.HasSuffix(fqdn, ".") {
+		fqdn += "."
+	}
+	name, err := dnsmessage.NewName(fqdn)
+	if err != nil {
+		return result, err
+	}
+
+	start := time.Now()
+	dialer := p.resolverDialer
+	if dnsSpec.Resolver != "" {
+		dialer = p.dialer
+	}
+
+	response, err := p.exchange(ctx, dialer, resolver, dnsmessage.Question{Name: name, Type: qtype, Class: dnsmessage.ClassINET})
+	result.Timings.DNSLookup = time.Since(start)
+	result.Timings.Total = result.Timings.DNSLookup
+	if err != nil {
+		return result, err
+	}
+
+	dnsResult.RCode = strings.TrimPrefix(response.RCode.String(), "RCode")
+	switch response.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return result, ErrNXDomain
+	case dnsmessage.RCodeServerFailure:
+		return result, ErrServFail
+	default:
+		return result, fmt.Errorf("%w: %s", ErrDNSQueryFailed, dnsResult.RCode)
+	}
+
+	for _, answer := range response.Answers {
+		if answer.Header.Type == qtype {
+			dnsResult.Answers = append(dnsResult.Answers, formatDNSRecord(answer.Body))
+		}
+	}
+
+	for _, expected := range dnsSpec.Expected {
+		if !slices.ContainsFunc(dnsResult.Answers, func(answer string) bool {
+			return normalizeDNSValue(answer) == normalizeDNSValue(expected)
+		}) {
+			return result, fmt.Errorf("%w: %q not found", ErrDNSMismatch, expected)
+		}
+	}
+
+	return result, nil
+}
+
+func (p *DNSProber) resolver(spec *DNSSpec) string {
+	resolver := spec.Resolver
+	if resolver == "" {
+		resolver = p.cfg.DNSResolver
+	}
+	if resolver == "" {
+		resolver = systemResolver()
+	}
+	return resolverAddress(resolver)
+}
+
+// resolverAddress adds the default dns port to a resolver given without one
+func resolverAddress(resolver string) string {
+	if _, _, err := net.SplitHostPort(resolver); err != nil {
+		return net.JoinHostPort(resolver, "53")
+	}
+	return resolver
+}
+
+// exchange sends the question over udp and retries over tcp when the answer is truncated
+func (p *DNSProber) exchange(ctx context.Context, dialer *net.Dialer, resolver string, question dnsmessage.Question) (*dnsmessage.Message, error) {
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: uint16(rand.Uint32()), RecursionDesired: true},
+		Questions: []dnsmessage.Question{question},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := p.roundTrip(ctx, dialer, "udp", resolver, packed)
+	if err == nil && response.Truncated {
+		response, err = p.roundTrip(ctx, dialer, "tcp", resolver, packed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if response.ID != query.ID {
+		return nil, fmt.Errorf("%w: response id mismatch", ErrDNSQueryFailed)
+	}
+
+	return response, nil
+}
+
+func (p *DNSProber) roundTrip(ctx context.Context, dialer *net.Dialer, network, resolver string, packed []byte) (*dnsmessage.Message, error) {
+	conn, err := dialer.DialContext(ctx, network, resolver)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	var raw []byte
+	if network == "tcp" {
+		framed := binary.BigEndian.AppendUint16(nil, uint16(len(packed)))
+		if _, err := conn.Write(append(framed, packed...)); err != nil {
+			return nil, contextError(ctx, err)
+		}
+		var length uint16
+		if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+			return nil, contextError(ctx, err)
+		}
+		raw = make([]byte, length)
+		if _, err := io.ReadFull(conn, raw); err != nil {
+			return nil, contextError(ctx, err)
+		}
+	} else {
+		if _, err := conn.Write(packed); err != nil {
+			return nil, contextError(ctx, err)
+		}
+		raw = make([]byte, 65535)
+		n, err := conn.Read(raw)
+		if err != nil {
+			return nil, contextError(ctx, err)
+		}
+		raw = raw[:n]
+	}
+
+	var response dnsmessage.Message
+	if err := response.Unpack(raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDNSQueryFailed, err)
+	}
+	return &response, nil
+}
+
+func formatDNSRecord(body dnsmessage.ResourceBody) string {
+	switch record := body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(record.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(record.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return record.CNAME.String()
+	case *dnsmessage.MXResource:
+		return strconv.Itoa(int(record.Pref)) + " " + record.MX.String()
+	case *dnsmessage.TXTResource:
+		return strings.Join(record.TXT, "")
+	}
+	return body.GoString()
+}
+
+// normalizeDNSValue makes answers comparable regardless of case and trailing dots
+func normalizeDNSValue(value string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), ".")
+}
+
+// systemResolver returns the first nameserver listed in /etc/resolv.conf
+func systemResolver() string {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return fallbackResolver
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], "53")
+		}
+	}
+	return fallbackResolver
+}
@@ -0,0 +1,97 @@
+package pinger_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startDNSServer answers A and MX queries for example.test,
+// NXDOMAIN for missing.test and SERVFAIL for everything else
+func startDNSServer(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			question := query.Questions[0]
+
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true},
+				Questions: query.Questions,
+			}
+			header := dnsmessage.ResourceHeader{Name: question.Name, Type: question.Type, Class: dnsmessage.ClassINET, TTL: 60}
+
+			switch {
+			case question.Name.String() == "example.test." && question.Type == dnsmessage.TypeA:
+				response.Answers = []dnsmessage.Resource{
+					{Header: header, Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}},
+				}
+			case question.Name.String() == "example.test." && question.Type == dnsmessage.TypeMX:
+				response.Answers = []dnsmessage.Resource{
+					{Header: header, Body: &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.example.test.")}},
+				}
+			case question.Name.String() == "missing.test.":
+				response.RCode = dnsmessage.RCodeNameError
+			default:
+				response.RCode = dnsmessage.RCodeServerFailure
+			}
+
+			packed, _ := response.Pack()
+			_, _ = conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestDNSProbeMatchesExpectedAnswers(t *testing.T) {
+	registry := newRegistry(t, &pinger.Config{DNSResolver: startDNSServer(t)})
+
+	result, err := registry.Probe(context.Background(), "dns://example.test", &pinger.Spec{
+		DNS: &pinger.DNSSpec{Expected: []string{"192.0.2.1"}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "dns", result.Scheme)
+	assert.Equal(t, []string{"192.0.2.1"}, result.DNS.Answers)
+	assert.Equal(t, "Success", result.DNS.RCode)
+
+	result, err = registry.Probe(context.Background(), "dns://example.test?type=mx", &pinger.Spec{
+		DNS: &pinger.DNSSpec{Expected: []string{"10 MAIL.example.test"}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10 mail.example.test."}, result.DNS.Answers)
+}
+
+func TestDNSProbeReportsFailures(t *testing.T) {
+	registry := newRegistry(t, &pinger.Config{DNSResolver: startDNSServer(t)})
+
+	_, err := registry.Probe(context.Background(), "dns://example.test", &pinger.Spec{
+		DNS: &pinger.DNSSpec{Expected: []string{"192.0.2.2"}},
+	})
+	assert.Equal(t, pinger.ErrorClassDNSMismatch, pinger.ClassifyError(err))
+
+	_, err = registry.Probe(context.Background(), "dns://missing.test", nil)
+	assert.Equal(t, pinger.ErrorClassNXDomain, pinger.ClassifyError(err))
+
+	_, err = registry.Probe(context.Background(), "dns://broken.test", nil)
+	assert.Equal(t, pinger.ErrorClassServFail, pinger.ClassifyError(err))
+}
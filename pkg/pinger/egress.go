@@ -0,0 +1,202 @@
+package pinger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var ErrBlocked = errors.New("blocked by egress policy")
+
+// EgressConfig restricts where probes may connect. Addresses are checked
+// after name resolution, right before each connection is made, so a name
+// that later resolves somewhere else cannot bypass the policy. An address
+// in AllowCIDRs is reachable even when it also falls in DenyCIDRs; empty
+// AllowedSchemes or AllowedPorts allow every scheme or port
+type EgressConfig struct {
+	DenyCIDRs      []string `koanf:"deny_cidrs"`
+	AllowCIDRs     []string `koanf:"allow_cidrs"`
+	AllowedSchemes []string `koanf:"allowed_schemes"`
+	AllowedPorts   []int    `koanf:"allowed_ports"`
+}
+
+type egressPolicy struct {
+	deny    []netip.Prefix
+	allow   []netip.Prefix
+	schemes []string
+	ports   []int
+	// lookup resolves the targets sent through a proxy
+	lookup func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+func newEgressPolicy(cfg EgressConfig) (*egressPolicy, error) {
+	policy := &egressPolicy{
+		ports: cfg.AllowedPorts,
+		lookup: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+	}
+
+	for _, scheme := range cfg.AllowedSchemes {
+		policy.schemes = append(policy.schemes, strings.ToLower(scheme))
+	}
+
+	var err error
+	if policy.deny, err = parsePrefixes(cfg.DenyCIDRs); err != nil {
+		return nil, fmt.Errorf("egress deny_cidrs: %w", err)
+	}
+	if policy.allow, err = parsePrefixes(cfg.AllowCIDRs); err != nil {
+		return nil, fmt.Errorf("egress allow_cidrs: %w", err)
+	}
+
+	return policy, nil
+}
+
+func parsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// checkAddr matches an ipv4 mapped ipv6 address both as it is and as the
+// ipv4 address it maps, so either form can be listed
+func (p *egressPolicy) checkAddr(addr netip.Addr) error {
+	contains := func(prefix netip.Prefix) bool { return prefix.Contains(addr) || prefix.Contains(addr.Unmap()) }
+
+	if slices.ContainsFunc(p.allow, contains) {
+		return nil
+	}
+	if slices.ContainsFunc(p.deny, contains) {
+		return fmt.Errorf("%w: address %s is not allowed", ErrBlocked, addr.Unmap())
+	}
+	return nil
+}
+
+// checkURL rejects targets whose scheme, port or literal address is not
+// allowed, before anything is resolved or dialed
+func (p *egressPolicy) checkURL(target *url.URL) error {
+	scheme := strings.ToLower(target.Scheme)
+	if len(p.schemes) > 0 && !slices.Contains(p.schemes, scheme) {
+		return fmt.Errorf("%w: scheme %s is not allowed", ErrBlocked, scheme)
+	}
+
+	// the host of a dns target is the name being looked up, not a peer
+	if scheme == "dns" {
+		return nil
+	}
+
+	if len(p.ports) > 0 {
+		port, err := strconv.Atoi(targetPort(target))
+		if err != nil || !slices.Contains(p.ports, port) {
+			return fmt.Errorf("%w: port %s is not allowed", ErrBlocked, targetPort(target))
+		}
+	}
+
+	if addr, err := netip.ParseAddr(target.Hostname()); err == nil {
+		return p.checkAddr(addr)
+	}
+	return nil
+}
+
+// checkProxied checks a target sent through a proxy. The proxy resolves and
+// dials the target itself, out of reach of control, so a name is resolved
+// here and refused when any of its addresses is. A name that cannot be
+// resolved is refused while addresses are denied, and a name the proxy
+// resolves differently is beyond what can be checked from here
+func (p *egressPolicy) checkProxied(ctx context.Context, target *url.URL) error {
+	if err := p.checkURL(target); err != nil {
+		return err
+	}
+
+	host := target.Hostname()
+	if _, err := netip.ParseAddr(host); err == nil || len(p.deny) == 0 {
+		return nil
+	}
+
+	addrs, err := p.lookup(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: resolving %s: %v", ErrBlocked, host, err)
+	}
+	for _, addr := range addrs {
+		if err := p.checkAddr(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkResolver rejects a resolver requested by a spec whose port or literal
+// address is not allowed, the resolver is a peer like any other target
+func (p *egressPolicy) checkResolver(resolver string) error {
+	host, port, err := net.SplitHostPort(resolverAddress(resolver))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlocked, err)
+	}
+
+	if len(p.ports) > 0 {
+		number, err := strconv.Atoi(port)
+		if err != nil || !slices.Contains(p.ports, number) {
+			return fmt.Errorf("%w: port %s is not allowed", ErrBlocked, port)
+		}
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(addr)
+	}
+	return nil
+}
+
+// control is installed on every probe dialer and sees the resolved address,
+// so redirects and proxies are held to the same addresses and ports. Targets
+// behind a proxy are only seen by checkProxied
+func (p *egressPolicy) control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlocked, err)
+	}
+	if len(p.ports) > 0 && !slices.Contains(p.ports, int(addrPort.Port())) {
+		return fmt.Errorf("%w: port %d is not allowed", ErrBlocked, addrPort.Port())
+	}
+	return p.checkAddr(addrPort.Addr())
+}
+
+func targetPort(target *url.URL) string {
+	if port := target.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(target.Scheme) {
+	case "http":
+		return "80"
+	case "https", "tls":
+		return "443"
+	}
+	return ""
+}
+
+// guardDialer makes dialer refuse connections the policy of cfg forbids.
+// An invalid policy refuses every connection rather than allowing them all
+func guardDialer(dialer *net.Dialer, cfg *Config) *net.Dialer {
+	policy, err := newEgressPolicy(cfg.Egress)
+	if err != nil {
+		dialer.Control = func(string, string, syscall.RawConn) error {
+			return fmt.Errorf("%w: %v", ErrBlocked, err)
+		}
+		return dialer
+	}
+
+	dialer.Control = policy.control
+	return dialer
+}
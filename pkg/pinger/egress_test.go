@@ -0,0 +1,127 @@
+package pinger_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var loopback = []string{"127.0.0.0/8", "::1/128"}
+
+func TestEgressPolicyBlocksDeniedAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	registry := newRegistry(t, &pinger.Config{Egress: pinger.EgressConfig{DenyCIDRs: loopback}})
+
+	assert.ErrorIs(t, registry.CheckTarget(server.URL), pinger.ErrBlocked)
+	_, err := registry.Probe(context.Background(), server.URL, nil)
+	assert.ErrorIs(t, err, pinger.ErrBlocked)
+
+	// a name passes the up front check and is stopped once resolved
+	byName := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	assert.NoError(t, registry.CheckTarget(byName))
+	_, err = registry.Probe(context.Background(), byName, nil)
+	assert.ErrorIs(t, err, pinger.ErrBlocked)
+	assert.Equal(t, pinger.ErrorClassBlocked, pinger.ClassifyError(err))
+
+	_, err = registry.Probe(context.Background(), "tcp://"+server.Listener.Addr().String(), nil)
+	assert.ErrorIs(t, err, pinger.ErrBlocked)
+}
+
+func TestEgressPolicyBlocksUnspecifiedAndMappedAddresses(t *testing.T) {
+	registry := newRegistry(t, &pinger.Config{Egress: pinger.EgressConfig{
+		DenyCIDRs: []string{"127.0.0.0/8", "::/128", "::1/128", "::ffff:0:0/96"},
+	}})
+
+	// dialing the unspecified address reaches loopback
+	assert.ErrorIs(t, registry.CheckTarget("http://[::]:8080"), pinger.ErrBlocked)
+	assert.ErrorIs(t, registry.CheckTarget("http://[::ffff:127.0.0.1]"), pinger.ErrBlocked)
+	assert.ErrorIs(t, registry.CheckTarget("http://[::ffff:8.8.8.8]"), pinger.ErrBlocked)
+	assert.NoError(t, registry.CheckTarget("http://8.8.8.8"))
+	assert.NoError(t, registry.CheckTarget("http://[2001:4860:4860::8888]"))
+
+	_, err := registry.Probe(context.Background(), "tcp://[::]:8080", nil)
+	assert.ErrorIs(t, err, pinger.ErrBlocked)
+}
+
+func TestEgressPolicyAllowListWinsOverDenyList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	registry := newRegistry(t, &pinger.Config{Egress: pinger.EgressConfig{
+		DenyCIDRs:  loopback,
+		AllowCIDRs: []string{"127.0.0.1/32"},
+	}})
+
+	_, err := registry.Probe(context.Background(), server.URL, nil)
+	assert.NoError(t, err)
+}
+
+func TestEgressPolicyRestrictsSchemesAndPorts(t *testing.T) {
+	registry := newRegistry(t, &pinger.Config{Egress: pinger.EgressConfig{
+		AllowedSchemes: []string{"https", "dns"},
+		AllowedPorts:   []int{443},
+	}})
+
+	assert.NoError(t, registry.CheckTarget("https://example.com/health"))
+	assert.NoError(t, registry.CheckTarget("dns://example.com"))
+	assert.ErrorIs(t, registry.CheckTarget("http://example.com"), pinger.ErrBlocked)
+	assert.ErrorIs(t, registry.CheckTarget("https://example.com:8443"), pinger.ErrBlocked)
+}
+
+func TestEgressPolicyOnlyGuardsRequestedResolvers(t *testing.T) {
+	resolver := startDNSServer(t)
+	registry := newRegistry(t, &pinger.Config{
+		DNSResolver: resolver,
+		Egress:      pinger.EgressConfig{DenyCIDRs: loopback},
+	})
+
+	_, err := registry.Probe(context.Background(), "dns://example.test", nil)
+	assert.NoError(t, err)
+
+	_, err = registry.Probe(context.Background(), "dns://example.test", &pinger.Spec{
+		DNS: &pinger.DNSSpec{Resolver: resolver},
+	})
+	assert.ErrorIs(t, err, pinger.ErrBlocked)
+}
+
+func TestEgressPolicyBlocksRedirectsToDisallowedPorts(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL, http.StatusFound)
+	}))
+	defer server.Close()
+
+	port, err := strconv.Atoi(server.URL[strings.LastIndex(server.URL, ":")+1:])
+	require.NoError(t, err)
+	registry := newRegistry(t, &pinger.Config{Egress: pinger.EgressConfig{AllowedPorts: []int{port}}})
+
+	assert.NoError(t, registry.CheckTarget(server.URL))
+	_, err = registry.Probe(context.Background(), server.URL, nil)
+	assert.ErrorIs(t, err, pinger.ErrBlocked)
+}
+
+func TestEgressPolicyChecksRequestedResolvers(t *testing.T) {
+	registry := newRegistry(t, &pinger.Config{Egress: pinger.EgressConfig{
+		DenyCIDRs:    loopback,
+		AllowedPorts: []int{53, 443},
+	}})
+
+	assert.NoError(t, registry.CheckResolver("1.1.1.1"))
+	assert.NoError(t, registry.CheckResolver("dns.example.com:53"))
+	assert.ErrorIs(t, registry.CheckResolver("1.1.1.1:8080"), pinger.ErrBlocked)
+	assert.ErrorIs(t, registry.CheckResolver("127.0.0.1:53"), pinger.ErrBlocked)
+	assert.ErrorIs(t, registry.CheckResolver("[::1]:53"), pinger.ErrBlocked)
+}
+
+func TestNewDefaultRegistryRejectsInvalidEgressPolicy(t *testing.T) {
+	_, err := pinger.NewDefaultRegistry(&pinger.Config{Egress: pinger.EgressConfig{DenyCIDRs: []string{"10.0.0.0/33"}}})
+	assert.Error(t, err)
+}
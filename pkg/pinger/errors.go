@@ -0,0 +1,118 @@
+package pinger
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/url"
+	"slices"
+	"syscall"
+)
+
+// Error classes reported by ClassifyError
+const (
+	ErrorClassTimeout          = "timeout"
+	ErrorClassDNS              = "dns"
+	ErrorClassRefused          = "connection_refused"
+	ErrorClassReset            = "connection_reset"
+	ErrorClassTLS              = "tls"
+	ErrorClassUnexpectedStatus = "unexpected_status"
+	ErrorClassAssertion        = "assertion_failed"
+	ErrorClassUnexpectedReply  = "unexpected_reply"
+	ErrorClassNXDomain         = "nxdomain"
+	ErrorClassServFail         = "servfail"
+	ErrorClassDNSMismatch      = "dns_mismatch"
+	ErrorClassRedirect         = "redirect"
+	ErrorClassBlocked          = "blocked"
+	ErrorClassOther            = "other"
+)
+
+var errorClasses = []string{
+	ErrorClassTimeout, ErrorClassDNS, ErrorClassRefused, ErrorClassReset, ErrorClassTLS,
+	ErrorClassUnexpectedStatus, ErrorClassAssertion, ErrorClassUnexpectedReply, ErrorClassNXDomain,
+	ErrorClassServFail, ErrorClassDNSMismatch, ErrorClassRedirect, ErrorClassBlocked, ErrorClassOther,
+}
+
+// IsErrorClass reports whether class is one of the values returned by ClassifyError
+func IsErrorClass(class string) bool {
+	return slices.Contains(errorClasses, class)
+}
+
+// ClassifyError maps an error returned while probing to one of the ErrorClass constants
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var verifyErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidCertErr x509.CertificateInvalidError
+
+	switch {
+	case errors.Is(err, ErrBlocked):
+		return ErrorClassBlocked
+	case errors.Is(err, ErrUnexpectedStatus):
+		return ErrorClassUnexpectedStatus
+	case errors.Is(err, ErrAssertionFailed):
+		return ErrorClassAssertion
+	case errors.Is(err, ErrUnexpectedReply):
+		return ErrorClassUnexpectedReply
+	case errors.Is(err, ErrNXDomain):
+		return ErrorClassNXDomain
+	case errors.Is(err, ErrServFail):
+		return ErrorClassServFail
+	case errors.Is(err, ErrDNSMismatch):
+		return ErrorClassDNSMismatch
+	case errors.Is(err, ErrDNSQueryFailed):
+		return ErrorClassDNS
+	case errors.Is(err, ErrTooManyRedirects), errors.Is(err, ErrRedirectNotAllowed):
+		return ErrorClassRedirect
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorClassRefused
+	case errors.Is(err, syscall.ECONNRESET):
+		return ErrorClassReset
+	case errors.As(err, &verifyErr), errors.As(err, &recordErr), errors.As(err, &unknownAuthorityErr),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidCertErr):
+		return ErrorClassTLS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	}
+
+	return ErrorClassOther
+}
+
+// NormalizeError returns a short, stable description of err without the
+// request method and url that net/http prefixes to client errors
+func NormalizeError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	switch ClassifyError(err) {
+	case ErrorClassTimeout:
+		return "timeout"
+	case ErrorClassRefused:
+		return "connection refused"
+	case ErrorClassReset:
+		return "connection reset by peer"
+	case ErrorClassDNS:
+		return "dns lookup failed: " + err.Error()
+	case ErrorClassTLS:
+		return "tls handshake failed: " + err.Error()
+	}
+
+	return err.Error()
+}
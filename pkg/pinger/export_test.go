@@ -0,0 +1,12 @@
+package pinger
+
+import (
+	"context"
+	"net/netip"
+)
+
+// SetProxiedLookup replaces how the http prober of r resolves the targets it
+// sends through a proxy
+func SetProxiedLookup(r *Registry, lookup func(ctx context.Context, host string) ([]netip.Addr, error)) {
+	r.probers["http"].(*HTTPProber).egress.lookup = lookup
+}
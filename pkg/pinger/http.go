@@ -0,0 +1,173 @@
+package pinger
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+)
+
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+// HTTPResult holds what was observed while probing an http(s) target
+type HTTPResult struct {
+	StatusCode        int
+	Proto             string
+	BytesRead         int64
+	FinalURL          string
+	AssertionFailures []string
+	Redirects         []RedirectHop
+	HTTPSDowngrade    bool
+	Proxy             string
+}
+
+// HTTPProber probes http and https targets
+type HTTPProber struct {
+	cfg    *Config
+	proxy  proxyFunc
+	egress *egressPolicy
+	// clients holds one client per TLS profile, the default one under ""
+	clients map[string]*http.Client
+}
+
+func NewHTTPProber(cfg *Config) (*HTTPProber, error) {
+	profiles, err := loadTLSProfiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	egress, err := newEgressPolicy(cfg.Egress)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := map[string]*http.Client{"": newClient(cfg, cfg.TLSClientConfig)}
+	for name, tlsConfig := range profiles {
+		clients[name] = newClient(cfg, tlsConfig)
+	}
+
+	return &HTTPProber{
+		cfg:     cfg,
+		proxy:   cfg.Proxy.proxyFunc(),
+		egress:  egress,
+		clients: clients,
+	}, nil
+}
+
+func newClient(cfg *Config, tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Transport: newTransport(cfg, tlsConfig),
+		// redirects are followed by the prober so every hop can be recorded
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (p *HTTPProber) clientFor(spec *Spec) (*http.Client, error) {
+	name := tlsProfileName(spec)
+	client, ok := p.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTLSProfile, name)
+	}
+	return client, nil
+}
+
+// Probe sends the request described by spec to target and checks the response.
+// Cancelling ctx aborts the request and releases its connection
+func (p *HTTPProber) Probe(ctx context.Context, target *url.URL, spec *Spec) (*Result, error) {
+	log.Printf("PINGING_URL: url=%s", target)
+
+	tr := newTracer()
+	ctx = httptrace.WithClientTrace(ctx, tr.clientTrace())
+
+	httpResult := &HTTPResult{}
+	result := &Result{HTTP: httpResult}
+
+	resp, err := p.follow(ctx, target, spec, httpResult)
+	if err != nil {
+		result.Timings = tr.finish()
+		result.TLS = tlsFailure(err, target.Hostname(), expiryWarningDays(p.cfg, spec))
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	httpResult.StatusCode = resp.StatusCode
+	httpResult.Proto = resp.Proto
+	httpResult.FinalURL = resp.Request.URL.String()
+
+	if resp.TLS != nil {
+		host := resp.Request.URL.Hostname()
+		if resp.TLS.ServerName != "" {
+			host = resp.TLS.ServerName
+		}
+		// a profile skipping verification leaves no verified chains behind
+		verified := len(resp.TLS.VerifiedChains) > 0
+		result.TLS = inspectTLS(resp.TLS, host, verified, expiryWarningDays(p.cfg, spec))
+	}
+
+	var body []byte
+	if spec != nil && spec.Assertions != nil {
+		body, err = io.ReadAll(io.LimitReader(resp.Body, spec.Assertions.bodyLimit()+1))
+		httpResult.BytesRead = int64(len(body))
+	} else {
+		httpResult.BytesRead, err = io.Copy(io.Discard, resp.Body)
+	}
+	result.Timings = tr.finish()
+	if err != nil {
+		return result, err
+	}
+
+	if !spec.Accepts(resp.StatusCode) {
+		return result, fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+
+	if spec != nil && spec.Assertions != nil {
+		limit := spec.Assertions.bodyLimit()
+		truncated := int64(len(body)) > limit
+		if truncated {
+			body = body[:limit]
+		}
+
+		httpResult.AssertionFailures = spec.Assertions.evaluate(body, truncated)
+		if len(httpResult.AssertionFailures) > 0 {
+			return result, fmt.Errorf("%w: %s", ErrAssertionFailed, httpResult.AssertionFailures[0])
+		}
+	}
+
+	return result, nil
+}
+
+// newRequest builds one request of a probe. Once a redirect left the original
+// host the Host override and credentials of the spec are no longer sent
+func newRequest(ctx context.Context, method, url, body string, spec *Spec, crossHost bool) (*http.Request, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec != nil {
+		for key, value := range spec.Headers {
+			if crossHost && isSensitiveHeader(key) {
+				continue
+			}
+			if strings.EqualFold(key, "Host") {
+				req.Host = value
+				continue
+			}
+			req.Header.Set(key, value)
+		}
+	}
+
+	return req, nil
+}
@@ -0,0 +1,167 @@
+package pinger_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(target string, spec *pinger.Spec) (*pinger.Result, error) {
+	registry, err := pinger.NewDefaultRegistry(&pinger.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return registry.Probe(context.Background(), target, spec)
+}
+
+func newRegistry(t *testing.T, cfg *pinger.Config) *pinger.Registry {
+	t.Helper()
+	registry, err := pinger.NewDefaultRegistry(cfg)
+	require.NoError(t, err)
+	return registry
+}
+
+func TestHTTPProbeRejectsUnexpectedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	result, err := probe(server.URL, nil)
+
+	assert.ErrorIs(t, err, pinger.ErrUnexpectedStatus)
+	assert.Equal(t, http.StatusServiceUnavailable, result.HTTP.StatusCode)
+}
+
+func TestHTTPProbeSendsSpec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer token" || string(body) != `{"ping":true}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	accepted, _ := pinger.ParseStatusRange("202")
+	result, err := probe(server.URL, &pinger.Spec{
+		Method:         "post",
+		Headers:        map[string]string{"Authorization": "Bearer token"},
+		Body:           `{"ping":true}`,
+		ExpectedStatus: []pinger.StatusRange{accepted},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, result.HTTP.StatusCode)
+}
+
+func TestParseStatusRange(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected pinger.StatusRange
+		wantErr  bool
+	}{
+		{value: "204", expected: pinger.StatusRange{Min: 204, Max: 204}},
+		{value: "200-299", expected: pinger.StatusRange{Min: 200, Max: 299}},
+		{value: "3xx", expected: pinger.StatusRange{Min: 300, Max: 399}},
+		{value: "299-200", wantErr: true},
+		{value: "9xx", wantErr: true},
+		{value: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := pinger.ParseStatusRange(tt.value)
+		if tt.wantErr {
+			assert.Error(t, err, tt.value)
+			continue
+		}
+		assert.NoError(t, err, tt.value)
+		assert.Equal(t, tt.expected, got)
+	}
+}
+
+func TestHTTPProbeRecordsResponseDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	result, err := probe(server.URL+"/old", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", result.HTTP.Proto)
+	assert.Equal(t, int64(5), result.HTTP.BytesRead)
+	assert.Equal(t, server.URL+"/new", result.HTTP.FinalURL)
+}
+
+func TestNormalizeErrorConnectionRefused(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	_, err := probe(url, nil)
+
+	assert.Equal(t, pinger.ErrorClassRefused, pinger.ClassifyError(err))
+	assert.Equal(t, "connection refused", pinger.NormalizeError(err))
+}
+
+func TestHTTPProbeRecordsTimings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	result, err := probe(server.URL, nil)
+
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, result.Timings.TimeToFirstByte, 20*time.Millisecond)
+	assert.Greater(t, result.Timings.TCPConnect, time.Duration(0))
+	assert.GreaterOrEqual(t, result.Timings.Total, result.Timings.TimeToFirstByte)
+}
+
+func TestRegistryRejectsUnknownScheme(t *testing.T) {
+	_, err := probe("gopher://example.com", nil)
+
+	assert.ErrorIs(t, err, pinger.ErrUnsupportedScheme)
+}
+
+func TestHTTPProbeAbortsOnContextTimeout(t *testing.T) {
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := newRegistry(t, &pinger.Config{}).Probe(ctx, server.URL, nil)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, pinger.ErrorClassTimeout, pinger.ClassifyError(err))
+	assert.Equal(t, "timeout", pinger.NormalizeError(err))
+
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("request was not aborted on the server side")
+	}
+}
@@ -0,0 +1,86 @@
+package pinger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a parsed subset of JSONPath: a root `$` followed by any number
+// of `.key`, `["key"]` and `[index]` segments
+type JSONPath struct {
+	raw      string
+	segments []any
+}
+
+func (p JSONPath) String() string {
+	return p.raw
+}
+
+// ParseJSONPath parses expressions such as `$.status`, `$.checks[0].name` or `$["content-type"]`
+func ParseJSONPath(path string) (JSONPath, error) {
+	parsed := JSONPath{raw: path}
+
+	if !strings.HasPrefix(path, "$") {
+		return parsed, fmt.Errorf("json path %q must start with $", path)
+	}
+
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return parsed, fmt.Errorf("json path %q has an empty key", path)
+			}
+			parsed.segments = append(parsed.segments, key)
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return parsed, fmt.Errorf("json path %q has an unclosed bracket", path)
+			}
+			inner := rest[1:end]
+			if unquoted, err := strconv.Unquote(inner); err == nil {
+				parsed.segments = append(parsed.segments, unquoted)
+			} else if index, err := strconv.Atoi(inner); err == nil && index >= 0 {
+				parsed.segments = append(parsed.segments, index)
+			} else {
+				return parsed, fmt.Errorf("json path %q has an invalid segment %q", path, inner)
+			}
+			rest = rest[end+1:]
+		default:
+			return parsed, fmt.Errorf("json path %q is malformed near %q", path, rest)
+		}
+	}
+
+	return parsed, nil
+}
+
+// Lookup walks doc, a value decoded by encoding/json, and returns the value at the path
+func (p JSONPath) Lookup(doc any) (any, bool) {
+	current := doc
+	for _, segment := range p.segments {
+		switch key := segment.(type) {
+		case string:
+			object, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if current, ok = object[key]; !ok {
+				return nil, false
+			}
+		case int:
+			array, ok := current.([]any)
+			if !ok || key >= len(array) {
+				return nil, false
+			}
+			current = array[key]
+		}
+	}
+	return current, true
+}
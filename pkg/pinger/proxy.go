@@ -0,0 +1,80 @@
+package pinger
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// ProxyConfig selects the proxy http probes are sent through. HTTPProxy and
+// HTTPSProxy take http, https or socks5 urls, NoProxy is a comma separated
+// list of hosts, domains and CIDRs reached directly in the NO_PROXY format.
+// Requests to localhost and loopback addresses never use a proxy. When
+// nothing is set the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables apply
+type ProxyConfig struct {
+	HTTPProxy  string `koanf:"http_proxy"`
+	HTTPSProxy string `koanf:"https_proxy"`
+	NoProxy    string `koanf:"no_proxy"`
+
+	// Direct sends every probe without a proxy, ignoring the environment
+	Direct bool `koanf:"direct"`
+}
+
+type proxyFunc func(*url.URL) (*url.URL, error)
+
+type proxyContextKey struct{}
+
+func (c *ProxyConfig) proxyFunc() proxyFunc {
+	switch {
+	case c == nil || *c == ProxyConfig{}:
+		return httpproxy.FromEnvironment().ProxyFunc()
+	case c.Direct:
+		return func(*url.URL) (*url.URL, error) { return nil, nil }
+	}
+
+	return (&httpproxy.Config{
+		HTTPProxy:  c.HTTPProxy,
+		HTTPSProxy: c.HTTPSProxy,
+		NoProxy:    c.NoProxy,
+	}).ProxyFunc()
+}
+
+// proxyFromContext is the Proxy function of the shared transport, it uses the
+// proxy selection the prober stored on the request context
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	if proxy, ok := req.Context().Value(proxyContextKey{}).(proxyFunc); ok {
+		return proxy(req.URL)
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// ParseProxyURL parses a proxy url and checks it uses a scheme the probes support
+func ParseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy url %q has no host", raw)
+	}
+
+	return u, nil
+}
+
+// withProxy picks the proxy selection of spec, falling back to the prober's
+func (p *HTTPProber) withProxy(ctx context.Context, spec *Spec) (context.Context, proxyFunc) {
+	proxy := p.proxy
+	if spec != nil && spec.Proxy != nil {
+		proxy = spec.Proxy.proxyFunc()
+	}
+	return context.WithValue(ctx, proxyContextKey{}, proxy), proxy
+}
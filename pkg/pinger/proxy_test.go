@@ -0,0 +1,109 @@
+package pinger_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+)
+
+// newForwardProxy answers every proxied request itself and remembers the last target
+func newForwardProxy(t *testing.T, seen *string) *httptest.Server {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*seen = r.URL.String()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy
+}
+
+func TestHTTPProbeGoesThroughProxy(t *testing.T) {
+	var seen string
+	proxy := newForwardProxy(t, &seen)
+	proxyURL := "http://user:secret@" + proxy.Listener.Addr().String()
+
+	registry := newRegistry(t, &pinger.Config{Proxy: pinger.ProxyConfig{HTTPProxy: proxyURL}})
+	result, err := registry.Probe(context.Background(), "http://probe.example/health", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "http://probe.example/health", seen)
+	assert.Equal(t, http.StatusNoContent, result.HTTP.StatusCode)
+	assert.Equal(t, "http://user:xxxxx@"+proxy.Listener.Addr().String(), result.HTTP.Proxy)
+}
+
+func TestHTTPProbeThroughProxyChecksResolvedTarget(t *testing.T) {
+	var seen string
+	proxy := newForwardProxy(t, &seen)
+
+	registry := newRegistry(t, &pinger.Config{
+		Proxy:  pinger.ProxyConfig{HTTPProxy: proxy.URL},
+		Egress: pinger.EgressConfig{DenyCIDRs: []string{"10.0.0.0/8"}},
+	})
+	// the proxy would resolve these names itself
+	pinger.SetProxiedLookup(registry, func(_ context.Context, host string) ([]netip.Addr, error) {
+		switch host {
+		case "internal.example":
+			return []netip.Addr{netip.MustParseAddr("93.184.216.34"), netip.MustParseAddr("10.0.0.5")}, nil
+		case "public.example":
+			return []netip.Addr{netip.MustParseAddr("93.184.216.34")}, nil
+		}
+		return nil, errors.New("no such host")
+	})
+
+	_, err := registry.Probe(context.Background(), "http://internal.example/metadata", nil)
+	assert.ErrorIs(t, err, pinger.ErrBlocked)
+	_, err = registry.Probe(context.Background(), "http://unknown.example/", nil)
+	assert.ErrorIs(t, err, pinger.ErrBlocked)
+	assert.Empty(t, seen, "a blocked target never reaches the proxy")
+
+	_, err = registry.Probe(context.Background(), "http://public.example/health", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://public.example/health", seen)
+}
+
+func TestHTTPProbeProxyOverrides(t *testing.T) {
+	var globalSeen, jobSeen string
+	global := newForwardProxy(t, &globalSeen)
+	job := newForwardProxy(t, &jobSeen)
+
+	registry := newRegistry(t, &pinger.Config{Proxy: pinger.ProxyConfig{HTTPProxy: global.URL}})
+
+	result, err := registry.Probe(context.Background(), "http://probe.example/", &pinger.Spec{
+		Proxy: &pinger.ProxyConfig{HTTPProxy: job.URL},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://probe.example/", jobSeen)
+	assert.Empty(t, globalSeen)
+	assert.Equal(t, job.URL, result.HTTP.Proxy)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	_, err = registry.Probe(context.Background(), "http://excluded.example/", &pinger.Spec{
+		Proxy: &pinger.ProxyConfig{HTTPProxy: job.URL, NoProxy: "other.example,excluded.example"},
+	})
+	assert.Error(t, err, "excluded hosts are dialed directly and do not resolve")
+	assert.Equal(t, "http://probe.example/", jobSeen)
+
+	result, err = registry.Probe(context.Background(), target.URL, &pinger.Spec{
+		Proxy: &pinger.ProxyConfig{Direct: true},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, result.HTTP.Proxy)
+	assert.Empty(t, globalSeen)
+}
+
+func TestParseProxyURL(t *testing.T) {
+	for _, valid := range []string{"http://proxy:3128", "https://proxy", "socks5://user:pw@proxy:1080"} {
+		_, err := pinger.ParseProxyURL(valid)
+		assert.NoError(t, err, valid)
+	}
+	for _, invalid := range []string{"proxy:3128", "ftp://proxy", "http://"} {
+		_, err := pinger.ParseProxyURL(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
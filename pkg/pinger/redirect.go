@@ -0,0 +1,167 @@
+package pinger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTooManyRedirects    = errors.New("too many redirects")
+	ErrRedirectNotAllowed  = errors.New("redirect not allowed")
+	defaultMaxRedirectHops = 10
+)
+
+// Redirect modes of a RedirectSpec
+const (
+	RedirectFollow   = "follow"
+	RedirectNone     = "none"
+	RedirectSameHost = "same_host"
+)
+
+// IsSupportedRedirectMode reports whether mode can be used in a RedirectSpec
+func IsSupportedRedirectMode(mode string) bool {
+	switch mode {
+	case RedirectFollow, RedirectNone, RedirectSameHost:
+		return true
+	}
+	return false
+}
+
+// RedirectSpec controls how http probes treat redirect responses.
+// With RedirectNone the redirect response itself is judged; with
+// RedirectSameHost a redirect to another host fails the probe
+type RedirectSpec struct {
+	Mode    string
+	MaxHops int
+}
+
+func (s *RedirectSpec) mode() string {
+	if s == nil || s.Mode == "" {
+		return RedirectFollow
+	}
+	return s.Mode
+}
+
+func (s *RedirectSpec) maxHops() int {
+	if s == nil || s.MaxHops <= 0 {
+		return defaultMaxRedirectHops
+	}
+	return s.MaxHops
+}
+
+// RedirectHop is one redirect response received on the way to the final url
+type RedirectHop struct {
+	URL        string
+	StatusCode int
+	Latency    time.Duration
+}
+
+// sensitiveHeaders are not forwarded when a redirect leaves the original host
+var sensitiveHeaders = []string{"Host", "Authorization", "Cookie", "Www-Authenticate"}
+
+func isSensitiveHeader(key string) bool {
+	return slices.ContainsFunc(sensitiveHeaders, func(header string) bool {
+		return strings.EqualFold(key, header)
+	})
+}
+
+// follow sends the request and walks the redirect chain according to the
+// spec, recording every hop on result. The client must not follow redirects itself
+func (p *HTTPProber) follow(ctx context.Context, target *url.URL, spec *Spec, result *HTTPResult) (*http.Response, error) {
+	var redirect *RedirectSpec
+	if spec != nil {
+		redirect = spec.Redirect
+	}
+
+	current := target
+	method := spec.method()
+	body := ""
+	if spec != nil {
+		body = spec.Body
+	}
+	crossHost := false
+	ctx, proxy := p.withProxy(ctx, spec)
+	client, err := p.clientFor(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		proxyURL, err := proxy(current)
+		if err != nil {
+			return nil, err
+		}
+		result.Proxy = ""
+		if proxyURL != nil {
+			result.Proxy = proxyURL.Redacted()
+			if err := p.egress.checkProxied(ctx, current); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := newRequest(ctx, method, current.String(), body, spec, crossHost)
+		if err != nil {
+			return nil, err
+		}
+
+		hopStart := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		location, err := resp.Location()
+		if !isRedirect(resp.StatusCode) || redirect.mode() == RedirectNone || err != nil {
+			return resp, nil
+		}
+
+		result.Redirects = append(result.Redirects, RedirectHop{
+			URL:        current.String(),
+			StatusCode: resp.StatusCode,
+			Latency:    time.Since(hopStart),
+		})
+		// drain a little so the connection can be reused for the next hop
+		io.CopyN(io.Discard, resp.Body, 4096)
+		resp.Body.Close()
+
+		if current.Scheme == "https" && location.Scheme == "http" {
+			result.HTTPSDowngrade = true
+		}
+		if !strings.EqualFold(location.Host, target.Host) {
+			if redirect.mode() == RedirectSameHost {
+				return nil, fmt.Errorf("%w: %s leaves %s", ErrRedirectNotAllowed, location, target.Host)
+			}
+			crossHost = true
+		}
+		if len(result.Redirects) > redirect.maxHops() {
+			return nil, fmt.Errorf("%w: stopped after %d hops", ErrTooManyRedirects, redirect.maxHops())
+		}
+
+		// same method rewriting rules as net/http's client
+		switch resp.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther:
+			if method != http.MethodGet && method != http.MethodHead {
+				method = http.MethodGet
+			}
+			body = ""
+		}
+
+		current = location
+	}
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
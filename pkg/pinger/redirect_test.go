@@ -0,0 +1,109 @@
+package pinger_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRedirectServer(t *testing.T, hops int, final string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/done" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		n := strings.Count(r.URL.Path, "/hop")
+		if n < hops {
+			http.Redirect(w, r, strings.TrimSuffix(r.URL.Path, "/")+"/hop", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, final, http.StatusMovedPermanently)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPProbeRecordsRedirectChain(t *testing.T) {
+	server := newRedirectServer(t, 2, "/done")
+
+	result, err := probe(server.URL+"/start", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, result.HTTP.StatusCode)
+	assert.Equal(t, server.URL+"/done", result.HTTP.FinalURL)
+	if assert.Len(t, result.HTTP.Redirects, 3) {
+		assert.Equal(t, server.URL+"/start", result.HTTP.Redirects[0].URL)
+		assert.Equal(t, http.StatusFound, result.HTTP.Redirects[0].StatusCode)
+		assert.Equal(t, http.StatusMovedPermanently, result.HTTP.Redirects[2].StatusCode)
+	}
+	assert.False(t, result.Degraded())
+}
+
+func TestHTTPProbeRedirectPolicies(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer other.Close()
+	server := newRedirectServer(t, 3, other.URL)
+
+	result, err := probe(server.URL, &pinger.Spec{Redirect: &pinger.RedirectSpec{Mode: pinger.RedirectNone}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusFound, result.HTTP.StatusCode)
+	assert.Empty(t, result.HTTP.Redirects)
+
+	result, err = probe(server.URL, &pinger.Spec{Redirect: &pinger.RedirectSpec{MaxHops: 2}})
+	assert.ErrorIs(t, err, pinger.ErrTooManyRedirects)
+	assert.Equal(t, pinger.ErrorClassRedirect, pinger.ClassifyError(err))
+	assert.Len(t, result.HTTP.Redirects, 3)
+
+	result, err = probe(server.URL, &pinger.Spec{Redirect: &pinger.RedirectSpec{Mode: pinger.RedirectSameHost}})
+	assert.ErrorIs(t, err, pinger.ErrRedirectNotAllowed)
+	assert.Len(t, result.HTTP.Redirects, 4)
+
+	_, err = probe(server.URL, nil)
+	assert.NoError(t, err)
+}
+
+func TestHTTPProbeRewritesMethodAndDropsCredentials(t *testing.T) {
+	var method, auth string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		auth = r.Header.Get("Authorization")
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL, http.StatusSeeOther)
+	}))
+	defer server.Close()
+
+	_, err := probe(server.URL, &pinger.Spec{
+		Method:  http.MethodPost,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		Body:    "payload",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodGet, method)
+	assert.Empty(t, auth)
+}
+
+func TestHTTPProbeFlagsHTTPSDowngrade(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL, http.StatusMovedPermanently)
+	}))
+	defer secure.Close()
+
+	registry := newRegistry(t, &pinger.Config{
+		TLSClientConfig: secure.Client().Transport.(*http.Transport).TLSClientConfig,
+	})
+	result, err := registry.Probe(context.Background(), secure.URL, nil)
+
+	assert.NoError(t, err)
+	assert.True(t, result.HTTP.HTTPSDowngrade)
+	assert.True(t, result.Degraded())
+}
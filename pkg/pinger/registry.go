@@ -0,0 +1,160 @@
+package pinger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var ErrUnsupportedScheme = errors.New("unsupported target scheme")
+
+// Result holds what was observed while probing a target. Scheme tells which
+// prober produced it and which of the typed results is set
+type Result struct {
+	Scheme  string
+	Timings Timings
+	HTTP    *HTTPResult
+	TCP     *TCPResult
+	TLS     *TLSResult
+	DNS     *DNSResult
+}
+
+// Degraded reports whether the target answered correctly but shows a
+// problem that needs attention, such as a certificate close to expiry
+// or a redirect from https to plain http
+func (r *Result) Degraded() bool {
+	if r.HTTP != nil && r.HTTP.HTTPSDowngrade {
+		return true
+	}
+	return r.TLS != nil && r.TLS.ExpiresSoon
+}
+
+// Prober checks a single target. Implementations must honor ctx cancellation
+type Prober interface {
+	Probe(ctx context.Context, target *url.URL, spec *Spec) (*Result, error)
+}
+
+// Registry dispatches targets to the Prober registered for their scheme
+type Registry struct {
+	mu          sync.RWMutex
+	probers     map[string]Prober
+	tlsProfiles []string
+	egress      *egressPolicy
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		probers: make(map[string]Prober),
+	}
+}
+
+// NewDefaultRegistry returns a registry with every built-in prober registered
+func NewDefaultRegistry(cfg *Config) (*Registry, error) {
+	registry := NewRegistry()
+
+	egress, err := newEgressPolicy(cfg.Egress)
+	if err != nil {
+		return nil, err
+	}
+	registry.egress = egress
+
+	httpProber, err := NewHTTPProber(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsProber, err := NewTLSProber(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.Register("http", httpProber)
+	registry.Register("https", httpProber)
+	registry.Register("tcp", NewTCPProber(cfg))
+	registry.Register("tls", tlsProber)
+	registry.Register("dns", NewDNSProber(cfg))
+
+	for name := range cfg.TLSProfiles {
+		registry.tlsProfiles = append(registry.tlsProfiles, name)
+	}
+
+	return registry, nil
+}
+
+// CheckTarget reports with ErrBlocked when the scheme, port or literal address
+// of target is refused by the egress policy. Names are only checked once
+// resolved, when a probe connects
+func (r *Registry) CheckTarget(target string) error {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+	if r.egress == nil {
+		return nil
+	}
+	return r.egress.checkURL(parsed)
+}
+
+// CheckResolver reports with ErrBlocked when the port or literal address of a
+// resolver requested by a DNS spec is refused by the egress policy
+func (r *Registry) CheckResolver(resolver string) error {
+	if r.egress == nil {
+		return nil
+	}
+	return r.egress.checkResolver(resolver)
+}
+
+// HasTLSProfile reports whether specs may select the named TLS profile
+func (r *Registry) HasTLSProfile(name string) bool {
+	return slices.Contains(r.tlsProfiles, name)
+}
+
+// Register makes prober handle every target with the given scheme
+func (r *Registry) Register(scheme string, prober Prober) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.probers[strings.ToLower(scheme)] = prober
+}
+
+// Supports reports whether a prober is registered for scheme
+func (r *Registry) Supports(scheme string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.probers[strings.ToLower(scheme)]
+	return ok
+}
+
+// Probe parses target and hands it to the prober registered for its scheme
+func (r *Registry) Probe(ctx context.Context, target string, spec *Spec) (*Result, error) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+
+	r.mu.RLock()
+	prober, ok := r.probers[scheme]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedScheme, parsed.Scheme)
+	}
+
+	if r.egress != nil {
+		if err := r.egress.checkURL(parsed); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := prober.Probe(ctx, parsed, spec)
+	if result != nil {
+		result.Scheme = scheme
+	}
+	return result, err
+}
@@ -0,0 +1,85 @@
+package pinger
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+const (
+	defaultRetryBackoffBase = 200 * time.Millisecond
+	defaultRetryBackoffCap  = 5 * time.Second
+)
+
+// DefaultRetryOn lists the error classes retried when a RetryPolicy names none
+var DefaultRetryOn = []string{ErrorClassTimeout, ErrorClassReset, ErrorClassRefused}
+
+// RetryPolicy decides whether a failed probe is attempted again and how long
+// to wait before doing so. Backoff grows exponentially from BackoffBase up to
+// BackoffCap; Jitter (0 to 1) is the fraction of each delay that is randomized
+type RetryPolicy struct {
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffCap  time.Duration
+	Jitter      float64
+	RetryOn     []string
+	RetryStatus []StatusRange
+}
+
+// Attempts returns how many times a target is probed at most
+func (p *RetryPolicy) Attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// Retryable reports whether the outcome of an attempt is worth retrying
+func (p *RetryPolicy) Retryable(result *Result, err error) bool {
+	if p == nil || err == nil {
+		return false
+	}
+
+	if errors.Is(err, ErrUnexpectedStatus) && result != nil && result.HTTP != nil {
+		for _, r := range p.RetryStatus {
+			if r.Contains(result.HTTP.StatusCode) {
+				return true
+			}
+		}
+	}
+
+	retryOn := p.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = DefaultRetryOn
+	}
+	return slices.Contains(retryOn, ClassifyError(err))
+}
+
+// Backoff returns the delay before the given retry, counting from 1
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	base, limit := defaultRetryBackoffBase, defaultRetryBackoffCap
+	jitter := 0.0
+	if p != nil {
+		if p.BackoffBase > 0 {
+			base = p.BackoffBase
+		}
+		if p.BackoffCap > 0 {
+			limit = p.BackoffCap
+		}
+		jitter = min(max(p.Jitter, 0), 1)
+	}
+
+	delay := limit
+	if retry < 1 {
+		retry = 1
+	}
+	if shift := retry - 1; shift < 32 && base<<shift < limit {
+		delay = base << shift
+	}
+
+	if jitter > 0 {
+		delay -= time.Duration(jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
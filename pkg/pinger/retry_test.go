@@ -0,0 +1,52 @@
+package pinger_test
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &pinger.RetryPolicy{
+		MaxAttempts: 5,
+		BackoffBase: 100 * time.Millisecond,
+		BackoffCap:  time.Second,
+	}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.Backoff(4))
+	assert.Equal(t, time.Second, policy.Backoff(5))
+	assert.Equal(t, time.Second, policy.Backoff(60))
+
+	policy.Jitter = 0.5
+	for range 20 {
+		delay := policy.Backoff(3)
+		assert.GreaterOrEqual(t, delay, 200*time.Millisecond)
+		assert.LessOrEqual(t, delay, 400*time.Millisecond)
+	}
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	serverError, _ := pinger.ParseStatusRange("5xx")
+	policy := &pinger.RetryPolicy{MaxAttempts: 3, RetryStatus: []pinger.StatusRange{serverError}}
+	statusErr := fmt.Errorf("%w: 503", pinger.ErrUnexpectedStatus)
+
+	assert.True(t, policy.Retryable(nil, syscall.ECONNRESET))
+	assert.True(t, policy.Retryable(&pinger.Result{HTTP: &pinger.HTTPResult{StatusCode: 503}}, statusErr))
+	assert.False(t, policy.Retryable(&pinger.Result{HTTP: &pinger.HTTPResult{StatusCode: 404}}, statusErr))
+	assert.False(t, policy.Retryable(nil, pinger.ErrAssertionFailed))
+	assert.False(t, policy.Retryable(nil, nil))
+
+	policy.RetryOn = []string{pinger.ErrorClassAssertion}
+	assert.True(t, policy.Retryable(nil, pinger.ErrAssertionFailed))
+	assert.False(t, policy.Retryable(nil, syscall.ECONNRESET))
+
+	var none *pinger.RetryPolicy
+	assert.Equal(t, 1, none.Attempts())
+	assert.False(t, none.Retryable(nil, syscall.ECONNRESET))
+}
@@ -0,0 +1,112 @@
+package pinger
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// Contains reports whether code falls inside the range
+func (r StatusRange) Contains(code int) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+// ParseStatusRange parses a status code ("204"), a range ("200-299")
+// or a class ("2xx") into a StatusRange
+func ParseStatusRange(value string) (StatusRange, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+
+	if len(value) == 3 && strings.HasSuffix(value, "xx") {
+		class, err := strconv.Atoi(value[:1])
+		if err != nil || class < 1 || class > 5 {
+			return StatusRange{}, fmt.Errorf("invalid status class %q", value)
+		}
+		return StatusRange{Min: class * 100, Max: class*100 + 99}, nil
+	}
+
+	low, high, isRange := strings.Cut(value, "-")
+	if !isRange {
+		high = low
+	}
+
+	min, err := parseStatusCode(low)
+	if err != nil {
+		return StatusRange{}, err
+	}
+	max, err := parseStatusCode(high)
+	if err != nil {
+		return StatusRange{}, err
+	}
+	if min > max {
+		return StatusRange{}, fmt.Errorf("invalid status range %q", value)
+	}
+
+	return StatusRange{Min: min, Max: max}, nil
+}
+
+func parseStatusCode(value string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("invalid status code %q", value)
+	}
+	return code, nil
+}
+
+// DefaultExpectedStatus is used when a Spec does not list accepted status codes
+var DefaultExpectedStatus = []StatusRange{{Min: 200, Max: 399}}
+
+// Spec describes how a target is probed and what counts as a healthy answer.
+// Retry is honoured by callers that probe repeatedly, Registry.Probe always
+// makes a single attempt
+type Spec struct {
+	Method         string
+	Headers        map[string]string
+	Body           string
+	ExpectedStatus []StatusRange
+	Assertions     *Assertions
+	TCP            *TCPSpec
+	TLS            *TLSSpec
+	DNS            *DNSSpec
+	Redirect       *RedirectSpec
+	Retry          *RetryPolicy
+	Proxy          *ProxyConfig
+}
+
+// Accepts reports whether the status code is one of the expected ones
+func (s *Spec) Accepts(code int) bool {
+	ranges := DefaultExpectedStatus
+	if s != nil && len(s.ExpectedStatus) > 0 {
+		ranges = s.ExpectedStatus
+	}
+
+	for _, r := range ranges {
+		if r.Contains(code) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Spec) method() string {
+	if s == nil || s.Method == "" {
+		return http.MethodGet
+	}
+	return strings.ToUpper(s.Method)
+}
+
+// IsSupportedMethod reports whether method can be used in a Spec
+func IsSupportedMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package pinger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+var ErrUnexpectedReply = errors.New("unexpected reply")
+
+// maxBannerBytes caps how much of the reply a TCP probe reads and keeps
+const maxBannerBytes = 4096
+
+// TCPSpec describes an optional exchange performed once a TCP connection is open.
+// When Expect or ExpectRegex is set the reply is read until it matches,
+// the peer closes the connection or the context expires
+type TCPSpec struct {
+	Send        string
+	Expect      string
+	ExpectRegex *regexp.Regexp
+}
+
+func (s *TCPSpec) expectsReply() bool {
+	return s != nil && (s.Expect != "" || s.ExpectRegex != nil)
+}
+
+func (s *TCPSpec) matches(reply []byte) bool {
+	if s.Expect != "" && !bytes.Contains(reply, []byte(s.Expect)) {
+		return false
+	}
+	if s.ExpectRegex != nil && !s.ExpectRegex.Match(reply) {
+		return false
+	}
+	return true
+}
+
+// TCPResult holds what was observed while probing a tcp target
+type TCPResult struct {
+	RemoteAddr string
+	Banner     string
+}
+
+// TCPProber probes tcp://host:port targets
+type TCPProber struct {
+	dialer *net.Dialer
+}
+
+func NewTCPProber(cfg *Config) *TCPProber {
+	return &TCPProber{
+		dialer: newDialer(cfg),
+	}
+}
+
+func (p *TCPProber) Probe(ctx context.Context, target *url.URL, spec *Spec) (*Result, error) {
+	log.Printf("PINGING_TCP: target=%s", target.Host)
+
+	if target.Port() == "" {
+		return nil, fmt.Errorf("tcp target %q has no port", target.Host)
+	}
+
+	start := time.Now()
+	conn, err := p.dialer.DialContext(ctx, "tcp", target.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tcpResult := &TCPResult{RemoteAddr: conn.RemoteAddr().String()}
+	result := &Result{TCP: tcpResult}
+	result.Timings.TCPConnect = time.Since(start)
+
+	var tcpSpec *TCPSpec
+	if spec != nil {
+		tcpSpec = spec.TCP
+	}
+
+	if tcpSpec != nil && (tcpSpec.Send != "" || tcpSpec.expectsReply()) {
+		// unblock reads and writes as soon as the probe is cancelled
+		stop := context.AfterFunc(ctx, func() {
+			conn.SetDeadline(time.Now())
+		})
+		defer stop()
+
+		err = exchange(ctx, conn, tcpSpec, tcpResult)
+	}
+
+	result.Timings.Total = time.Since(start)
+	return result, err
+}
+
+// exchange writes the payload and reads the reply until it satisfies the spec
+func exchange(ctx context.Context, conn net.Conn, spec *TCPSpec, result *TCPResult) error {
+	if spec.Send != "" {
+		if _, err := io.WriteString(conn, spec.Send); err != nil {
+			return contextError(ctx, err)
+		}
+	}
+
+	if !spec.expectsReply() {
+		return nil
+	}
+
+	reply := make([]byte, 0, 512)
+	buf := make([]byte, 512)
+	for len(reply) < maxBannerBytes {
+		n, err := conn.Read(buf)
+		reply = append(reply, buf[:n]...)
+		if len(reply) > maxBannerBytes {
+			reply = reply[:maxBannerBytes]
+		}
+		result.Banner = string(reply)
+
+		if spec.matches(reply) {
+			return nil
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return contextError(ctx, err)
+		}
+	}
+
+	return fmt.Errorf("%w: %q", ErrUnexpectedReply, result.Banner)
+}
+
+// contextError prefers the context error over the i/o error it caused
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package pinger_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTCPServer accepts connections and hands each one to handle
+func startTCPServer(t *testing.T, handle func(net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestTCPProbeMatchesReply(t *testing.T) {
+	addr := startTCPServer(t, func(conn net.Conn) {
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		if line == "PING\r\n" {
+			_, _ = conn.Write([]byte("+PONG\r\n"))
+		}
+	})
+
+	result, err := probe("tcp://"+addr, &pinger.Spec{
+		TCP: &pinger.TCPSpec{Send: "PING\r\n", ExpectRegex: regexp.MustCompile(`^\+PONG`)},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "tcp", result.Scheme)
+	assert.Equal(t, "+PONG\r\n", result.TCP.Banner)
+	assert.Greater(t, result.Timings.TCPConnect, time.Duration(0))
+}
+
+func TestTCPProbeReportsUnexpectedBanner(t *testing.T) {
+	addr := startTCPServer(t, func(conn net.Conn) {
+		_, _ = conn.Write([]byte("554 go away\r\n"))
+	})
+
+	result, err := probe("tcp://"+addr, &pinger.Spec{
+		TCP: &pinger.TCPSpec{Expect: "220"},
+	})
+
+	assert.ErrorIs(t, err, pinger.ErrUnexpectedReply)
+	assert.Equal(t, "554 go away\r\n", result.TCP.Banner)
+}
+
+func TestTCPProbeReportsRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, err = probe("tcp://"+addr, nil)
+
+	assert.Equal(t, pinger.ErrorClassRefused, pinger.ClassifyError(err))
+}
+
+func TestTCPProbeTimesOutWaitingForReply(t *testing.T) {
+	addr := startTCPServer(t, func(conn net.Conn) {
+		time.Sleep(time.Second)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := newRegistry(t, &pinger.Config{}).Probe(ctx, "tcp://"+addr, &pinger.Spec{
+		TCP: &pinger.TCPSpec{Expect: "220"},
+	})
+
+	assert.Equal(t, pinger.ErrorClassTimeout, pinger.ClassifyError(err))
+}
@@ -0,0 +1,197 @@
+package pinger
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+)
+
+// CertificateInfo describes one certificate of the chain presented by a peer
+type CertificateInfo struct {
+	Subject         string
+	Issuer          string
+	DNSNames        []string
+	NotBefore       time.Time
+	NotAfter        time.Time
+	DaysUntilExpiry int
+}
+
+// TLSResult holds what was observed during a TLS handshake
+type TLSResult struct {
+	Version       string
+	ServerName    string
+	Chain         []CertificateInfo
+	HostnameValid bool
+	Verified      bool
+	VerifyError   string
+	ExpiresSoon   bool
+}
+
+// TLSSpec tunes how the certificate presented by a peer is judged
+type TLSSpec struct {
+	// ExpiryWarningDays overrides Config.CertExpiryWarningDays when set
+	ExpiryWarningDays int
+	// Profile names the entry of Config.TLSProfiles the handshake uses
+	Profile string
+}
+
+func expiryWarningDays(cfg *Config, spec *Spec) int {
+	if spec != nil && spec.TLS != nil && spec.TLS.ExpiryWarningDays > 0 {
+		return spec.TLS.ExpiryWarningDays
+	}
+	return cfg.CertExpiryWarningDays
+}
+
+// inspectTLS summarizes the connection state; verified tells whether the
+// chain was already validated by the caller
+func inspectTLS(state *tls.ConnectionState, host string, verified bool, warningDays int) *TLSResult {
+	result := &TLSResult{
+		Version:    tls.VersionName(state.Version),
+		ServerName: state.ServerName,
+		Verified:   verified,
+	}
+
+	for _, cert := range state.PeerCertificates {
+		result.Chain = append(result.Chain, CertificateInfo{
+			Subject:         cert.Subject.String(),
+			Issuer:          cert.Issuer.String(),
+			DNSNames:        cert.DNSNames,
+			NotBefore:       cert.NotBefore,
+			NotAfter:        cert.NotAfter,
+			DaysUntilExpiry: int(time.Until(cert.NotAfter).Hours() / 24),
+		})
+	}
+
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		result.HostnameValid = leaf.VerifyHostname(host) == nil
+		result.ExpiresSoon = warningDays > 0 && time.Until(leaf.NotAfter) < time.Duration(warningDays)*24*time.Hour
+	}
+
+	return result
+}
+
+// TLSProber performs a TLS handshake with tls://host:port targets
+// and reports on the certificate chain
+type TLSProber struct {
+	cfg      *Config
+	dialer   *net.Dialer
+	profiles map[string]*tls.Config
+}
+
+func NewTLSProber(cfg *Config) (*TLSProber, error) {
+	profiles, err := loadTLSProfiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TLSProber{
+		cfg:      cfg,
+		dialer:   newDialer(cfg),
+		profiles: profiles,
+	}, nil
+}
+
+func (p *TLSProber) Probe(ctx context.Context, target *url.URL, spec *Spec) (*Result, error) {
+	log.Printf("PINGING_TLS: target=%s", target.Host)
+
+	host := target.Hostname()
+	addr := target.Host
+	if target.Port() == "" {
+		addr = net.JoinHostPort(host, "443")
+	}
+
+	tlsConfig := &tls.Config{}
+	if name := tlsProfileName(spec); name != "" {
+		profile, ok := p.profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTLSProfile, name)
+		}
+		tlsConfig = profile.Clone()
+	} else if p.cfg.TLSClientConfig != nil {
+		tlsConfig = p.cfg.TLSClientConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+	// the chain is verified below so that it can be reported even when invalid
+	verify := !tlsConfig.InsecureSkipVerify
+	tlsConfig.InsecureSkipVerify = true
+
+	start := time.Now()
+	rawConn, err := p.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer rawConn.Close()
+
+	result := &Result{}
+	result.Timings.TCPConnect = time.Since(start)
+
+	handshakeStart := time.Now()
+	conn := tls.Client(rawConn, tlsConfig)
+	if err := conn.HandshakeContext(ctx); err != nil {
+		result.TLS = tlsFailure(err, tlsConfig.ServerName, expiryWarningDays(p.cfg, spec))
+		return result, err
+	}
+	result.Timings.TLSHandshake = time.Since(handshakeStart)
+	result.Timings.Total = time.Since(start)
+
+	state := conn.ConnectionState()
+	result.TLS = inspectTLS(&state, tlsConfig.ServerName, false, expiryWarningDays(p.cfg, spec))
+
+	if !verify {
+		return result, nil
+	}
+
+	if err := verifyChain(state.PeerCertificates, tlsConfig); err != nil {
+		result.TLS.VerifyError = err.Error()
+		return result, &tls.CertificateVerificationError{UnverifiedCertificates: state.PeerCertificates, Err: err}
+	}
+	result.TLS.Verified = true
+
+	return result, nil
+}
+
+// tlsFailure describes a handshake that failed, including the chain the
+// peer presented when it was rejected during verification. It returns nil
+// for errors that are not TLS related
+func tlsFailure(err error, host string, warningDays int) *TLSResult {
+	if ClassifyError(err) != ErrorClassTLS {
+		return nil
+	}
+
+	var verifyErr *tls.CertificateVerificationError
+	if errors.As(err, &verifyErr) {
+		state := &tls.ConnectionState{PeerCertificates: verifyErr.UnverifiedCertificates}
+		result := inspectTLS(state, host, false, warningDays)
+		result.VerifyError = verifyErr.Err.Error()
+		return result
+	}
+
+	return &TLSResult{VerifyError: err.Error()}
+}
+
+func verifyChain(certs []*x509.Certificate, tlsConfig *tls.Config) error {
+	if len(certs) == 0 {
+		return x509.CertificateInvalidError{Reason: x509.NotAuthorizedToSign, Detail: "no certificate presented"}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         tlsConfig.RootCAs,
+		Intermediates: intermediates,
+		DNSName:       tlsConfig.ServerName,
+	})
+	return err
+}
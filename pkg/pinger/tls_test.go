@@ -0,0 +1,123 @@
+package pinger_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTLSServer starts an https server whose certificate is signed by a
+// freshly generated CA and expires after validFor
+func newTLSServer(t *testing.T, validFor time.Duration) (*httptest.Server, *x509.CertPool) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gofetch test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, caCert, &leafKey.PublicKey, caKey)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{leafDER, caDER}, PrivateKey: leafKey}},
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	return server, roots
+}
+
+func TestHTTPSProbeMarksExpiringCertificateDegraded(t *testing.T) {
+	server, roots := newTLSServer(t, 5*24*time.Hour)
+	registry := newRegistry(t, &pinger.Config{
+		CertExpiryWarningDays: 14,
+		TLSClientConfig:       &tls.Config{RootCAs: roots},
+	})
+
+	result, err := registry.Probe(context.Background(), server.URL, nil)
+
+	require.NoError(t, err)
+	assert.True(t, result.Degraded())
+	assert.True(t, result.TLS.Verified)
+	assert.True(t, result.TLS.HostnameValid)
+	assert.Len(t, result.TLS.Chain, 2)
+	assert.Equal(t, 4, result.TLS.Chain[0].DaysUntilExpiry)
+	assert.Equal(t, "CN=gofetch test ca", result.TLS.Chain[0].Issuer)
+}
+
+func TestTLSProbeInspectsCertificate(t *testing.T) {
+	server, roots := newTLSServer(t, 90*24*time.Hour)
+	target := "tls://" + strings.TrimPrefix(server.URL, "https://")
+	registry := newRegistry(t, &pinger.Config{
+		CertExpiryWarningDays: 14,
+		TLSClientConfig:       &tls.Config{RootCAs: roots},
+	})
+
+	result, err := registry.Probe(context.Background(), target, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "tls", result.Scheme)
+	assert.False(t, result.Degraded())
+	assert.True(t, result.TLS.Verified)
+	assert.Equal(t, "CN=localhost", result.TLS.Chain[0].Subject)
+	assert.Equal(t, []string{"localhost"}, result.TLS.Chain[0].DNSNames)
+
+	result, err = registry.Probe(context.Background(), target, &pinger.Spec{
+		TLS: &pinger.TLSSpec{ExpiryWarningDays: 120},
+	})
+
+	require.NoError(t, err)
+	assert.True(t, result.Degraded())
+}
+
+func TestTLSProbeReportsUntrustedChain(t *testing.T) {
+	server, _ := newTLSServer(t, 90*24*time.Hour)
+	target := "tls://" + strings.TrimPrefix(server.URL, "https://")
+
+	result, err := newRegistry(t, &pinger.Config{}).Probe(context.Background(), target, nil)
+
+	assert.Equal(t, pinger.ErrorClassTLS, pinger.ClassifyError(err))
+	assert.False(t, result.TLS.Verified)
+	assert.NotEmpty(t, result.TLS.VerifyError)
+	assert.Len(t, result.TLS.Chain, 2)
+}
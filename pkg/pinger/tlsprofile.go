@@ -0,0 +1,97 @@
+package pinger
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var ErrUnknownTLSProfile = errors.New("unknown tls profile")
+
+// TLSProfile is a named TLS client setup probes can opt into, used for
+// targets behind a private CA or requiring a client certificate
+type TLSProfile struct {
+	// CAFile is a PEM bundle trusted instead of the system roots
+	CAFile string `koanf:"ca_file"`
+	// CertFile and KeyFile hold the client certificate presented for mTLS
+	CertFile string `koanf:"cert_file"`
+	KeyFile  string `koanf:"key_file"`
+	// MinVersion is the lowest accepted TLS version: 1.0, 1.1, 1.2 or 1.3
+	MinVersion string `koanf:"min_version"`
+	// ServerName overrides the name sent in SNI and checked against the certificate
+	ServerName string `koanf:"server_name"`
+	// InsecureSkipVerify accepts any certificate, it is never implied by other settings
+	InsecureSkipVerify bool `koanf:"insecure_skip_verify"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsConfig builds the client configuration of the profile on top of base
+func (p TLSProfile) tlsConfig(base *tls.Config) (*tls.Config, error) {
+	config := &tls.Config{}
+	if base != nil {
+		config = base.Clone()
+	}
+
+	if p.CAFile != "" {
+		pem, err := os.ReadFile(p.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca bundle: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", p.CAFile)
+		}
+		config.RootCAs = roots
+	}
+
+	if p.CertFile != "" || p.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if p.MinVersion != "" {
+		version, ok := tlsVersions[p.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported min tls version %q", p.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if p.ServerName != "" {
+		config.ServerName = p.ServerName
+	}
+	config.InsecureSkipVerify = p.InsecureSkipVerify
+
+	return config, nil
+}
+
+// loadTLSProfiles builds the client configuration of every profile in cfg
+func loadTLSProfiles(cfg *Config) (map[string]*tls.Config, error) {
+	profiles := make(map[string]*tls.Config, len(cfg.TLSProfiles))
+	for name, profile := range cfg.TLSProfiles {
+		config, err := profile.tlsConfig(cfg.TLSClientConfig)
+		if err != nil {
+			return nil, fmt.Errorf("tls profile %s: %w", name, err)
+		}
+		profiles[name] = config
+	}
+	return profiles, nil
+}
+
+func tlsProfileName(spec *Spec) string {
+	if spec == nil || spec.TLS == nil {
+		return ""
+	}
+	return spec.TLS.Profile
+}
@@ -0,0 +1,165 @@
+package pinger_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/pinger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gofetch private ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue signs a leaf certificate for usage and returns it with its key
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func writePEM(t *testing.T, path, kind string, der []byte) string {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600))
+	return path
+}
+
+// newMTLSServer starts an https server trusted only through a private CA that
+// requires a client certificate, and returns a profile able to reach it
+func newMTLSServer(t *testing.T) (*httptest.Server, pinger.TLSProfile) {
+	ca := newTestCA(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{ca.issue(t, "internal.example", x509.ExtKeyUsageServerAuth)},
+		ClientCAs:    roots,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	client := ca.issue(t, "prober", x509.ExtKeyUsageClientAuth)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(client.PrivateKey)
+	require.NoError(t, err)
+
+	return server, pinger.TLSProfile{
+		CAFile:     writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", ca.cert.Raw),
+		CertFile:   writePEM(t, filepath.Join(dir, "client.pem"), "CERTIFICATE", client.Certificate[0]),
+		KeyFile:    writePEM(t, filepath.Join(dir, "client-key.pem"), "PRIVATE KEY", keyDER),
+		MinVersion: "1.2",
+		ServerName: "internal.example",
+	}
+}
+
+func TestProbesUseTLSProfile(t *testing.T) {
+	server, profile := newMTLSServer(t)
+	registry := newRegistry(t, &pinger.Config{
+		TLSProfiles: map[string]pinger.TLSProfile{"internal": profile},
+	})
+	spec := &pinger.Spec{TLS: &pinger.TLSSpec{Profile: "internal"}}
+
+	result, err := registry.Probe(context.Background(), server.URL, spec)
+	require.NoError(t, err)
+	assert.True(t, result.TLS.Verified)
+	assert.Equal(t, "CN=gofetch private ca", result.TLS.Chain[0].Issuer)
+
+	result, err = registry.Probe(context.Background(), "tls://"+strings.TrimPrefix(server.URL, "https://"), spec)
+	require.NoError(t, err)
+	assert.True(t, result.TLS.Verified)
+	assert.Equal(t, "internal.example", result.TLS.ServerName)
+
+	assert.True(t, registry.HasTLSProfile("internal"))
+	assert.False(t, registry.HasTLSProfile("missing"))
+	_, err = registry.Probe(context.Background(), server.URL, &pinger.Spec{TLS: &pinger.TLSSpec{Profile: "missing"}})
+	assert.ErrorIs(t, err, pinger.ErrUnknownTLSProfile)
+}
+
+func TestHTTPSProbeRecordsVerificationFailure(t *testing.T) {
+	server, _ := newMTLSServer(t)
+
+	result, err := newRegistry(t, &pinger.Config{}).Probe(context.Background(), server.URL, nil)
+
+	assert.Equal(t, pinger.ErrorClassTLS, pinger.ClassifyError(err))
+	require.NotNil(t, result.TLS)
+	assert.False(t, result.TLS.Verified)
+	assert.NotEmpty(t, result.TLS.VerifyError)
+	assert.Equal(t, "CN=internal.example", result.TLS.Chain[0].Subject)
+}
+
+func TestTLSProfileInsecureSkipVerifyIsExplicit(t *testing.T) {
+	server, profile := newMTLSServer(t)
+	profile.CAFile = ""
+
+	registry := newRegistry(t, &pinger.Config{TLSProfiles: map[string]pinger.TLSProfile{"untrusted": profile}})
+	_, err := registry.Probe(context.Background(), server.URL, &pinger.Spec{TLS: &pinger.TLSSpec{Profile: "untrusted"}})
+	assert.Equal(t, pinger.ErrorClassTLS, pinger.ClassifyError(err))
+
+	profile.InsecureSkipVerify = true
+	registry = newRegistry(t, &pinger.Config{TLSProfiles: map[string]pinger.TLSProfile{"insecure": profile}})
+	result, err := registry.Probe(context.Background(), server.URL, &pinger.Spec{TLS: &pinger.TLSSpec{Profile: "insecure"}})
+	assert.NoError(t, err)
+	assert.False(t, result.TLS.Verified)
+}
+
+func TestNewDefaultRegistryRejectsBrokenProfile(t *testing.T) {
+	_, err := pinger.NewDefaultRegistry(&pinger.Config{
+		TLSProfiles: map[string]pinger.TLSProfile{"broken": {CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+	})
+	assert.ErrorContains(t, err, "tls profile broken")
+
+	_, err = pinger.NewDefaultRegistry(&pinger.Config{
+		TLSProfiles: map[string]pinger.TLSProfile{"old": {MinVersion: "0.9"}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package pinger
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+func msToDuration(ms int) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+// newDialer builds the dialer shared by all probers, guarded by the egress
+// policy. Zero values keep the net package defaults; a negative keep-alive
+// disables tcp keep-alive probes
+func newDialer(cfg *Config) *net.Dialer {
+	return guardDialer(newUnguardedDialer(cfg), cfg)
+}
+
+// newUnguardedDialer is only for peers chosen by the operator, such as the
+// configured dns resolver
+func newUnguardedDialer(cfg *Config) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   msToDuration(cfg.Transport.DialTimeoutMs),
+		KeepAlive: msToDuration(cfg.Transport.KeepAliveMs),
+	}
+}
+
+// newTransport builds the http transport shared by every http probe so that
+// connections are pooled and bounded instead of opened per request
+func newTransport(cfg *Config, tlsConfig *tls.Config) *http.Transport {
+	transport := &http.Transport{
+		Proxy:               proxyFromContext,
+		DialContext:         newDialer(cfg).DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        cfg.Transport.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.Transport.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.Transport.MaxConnsPerHost,
+		IdleConnTimeout:     msToDuration(cfg.Transport.IdleConnTimeoutMs),
+		TLSHandshakeTimeout: msToDuration(cfg.Transport.TLSHandshakeTimeoutMs),
+		DisableKeepAlives:   cfg.Transport.DisableKeepAlives,
+	}
+
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig.Clone()
+	}
+
+	return transport
+}
@@ -0,0 +1,313 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/worker"
+)
+
+// ErrQueueFull is returned by Submit when admitting the job would queue
+// more tasks than the scheduler accepts
+var ErrQueueFull = errors.New("scheduler queue is full")
+
+// pollInterval is how often throttled tasks are retried when no limiter
+// can tell how long they have to wait
+const pollInterval = 50 * time.Millisecond
+
+// Config sizes the scheduler. Workers is how many tasks run at once across
+// every job and MaxQueued how many tasks may wait for a worker, 0 meaning no limit
+type Config struct {
+	Workers   int
+	MaxQueued int
+}
+
+// Job describes work scheduled together. Jobs share the workers in
+// proportion to their Weight and their tasks only start once Limiter lets
+// their key through. Size is how many tasks the job is going to run, they
+// count against MaxQueued until they start
+type Job struct {
+	Weight  int
+	Size    int
+	Limiter worker.Limiter
+}
+
+// Ticket is an admitted job. Its tasks take a worker with Acquire, however
+// many of them ask at once, and Done hands back what the job did not use
+type Ticket struct {
+	s *Scheduler
+	q *queue
+}
+
+// waiter is a task blocked in Acquire
+type waiter struct {
+	key     string
+	granted chan func()
+}
+
+type queue struct {
+	job     Job
+	waiters []*waiter
+	// queued is how many of the tasks of the job have not started yet
+	queued int
+	// pass is the virtual time of the job, it advances by 1/Weight for every
+	// task started and the job with the lowest pass goes next
+	pass float64
+}
+
+// Scheduler hands out one shared set of workers to the tasks of every
+// submitted job, picking between jobs with weighted fair queuing
+type Scheduler struct {
+	cfg    Config
+	mu     sync.Mutex
+	queues []*queue
+	queued int
+	// budget holds a slot for every running task
+	budget *worker.Budget
+	// vtime is the pass of the last job a task was started for, new jobs start
+	// there so a job that was idle cannot claim the time it did not use
+	vtime float64
+	wake  chan struct{}
+}
+
+// New creates a scheduler, Run has to be called for tasks to start
+func New(cfg Config) *Scheduler {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	return &Scheduler{
+		cfg:    cfg,
+		budget: worker.NewBudget(cfg.Workers),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Workers returns how many tasks run at once
+func (s *Scheduler) Workers() int {
+	return s.cfg.Workers
+}
+
+// Submit admits job and returns the ticket its tasks acquire workers with.
+// It fails with ErrQueueFull instead of queueing beyond MaxQueued
+func (s *Scheduler) Submit(job Job) (*Ticket, error) {
+	if job.Weight < 1 {
+		job.Weight = 1
+	}
+	job.Size = max(job.Size, 0)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cfg.MaxQueued > 0 && job.Size > 0 && s.queued+job.Size > s.cfg.MaxQueued {
+		return nil, ErrQueueFull
+	}
+
+	q := &queue{job: job, queued: job.Size, pass: s.vtime}
+	s.queues = append(s.queues, q)
+	s.queued += job.Size
+	return &Ticket{s: s, q: q}, nil
+}
+
+// Acquire blocks until the job may start a task for key and returns the
+// function that gives the worker back once the task is done. It fails with
+// the error of ctx when ctx is done first
+func (t *Ticket) Acquire(ctx context.Context, key string) (func(), error) {
+	s := t.s
+	w := &waiter{key: key, granted: make(chan func(), 1)}
+
+	s.mu.Lock()
+	t.q.waiters = append(t.q.waiters, w)
+	s.mu.Unlock()
+	s.signal()
+
+	select {
+	case release := <-w.granted:
+		return release, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	i := slices.Index(t.q.waiters, w)
+	if i >= 0 {
+		t.q.waiters = slices.Delete(t.q.waiters, i, i+1)
+	}
+	s.mu.Unlock()
+
+	// the worker was handed over while ctx was done
+	if i < 0 {
+		release := <-w.granted
+		release()
+	}
+	return nil, ctx.Err()
+}
+
+// Done tells the scheduler the job starts no more tasks, the ones it
+// announced but did not run no longer count as queued. It is called once
+// no Acquire of the ticket is pending
+func (t *Ticket) Done() {
+	s := t.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queued -= t.q.queued
+	t.q.queued = 0
+	if i := slices.Index(s.queues, t.q); i >= 0 {
+		s.queues = slices.Delete(s.queues, i, i+1)
+	}
+}
+
+// Queued returns how many tasks of admitted jobs have not started yet
+func (s *Scheduler) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queued
+}
+
+// Waiting returns how many tasks are blocked in Acquire
+func (s *Scheduler) Waiting() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	waiting := 0
+	for _, q := range s.queues {
+		waiting += len(q.waiters)
+	}
+	return waiting
+}
+
+// Run hands out workers until ctx is done. Tasks still waiting by then
+// only return once their own context is done
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		wait := s.dispatch()
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// dispatch hands the free workers to waiting tasks. It returns how long to
+// wait before trying again when tasks are held back by limiters, 0 when
+// only a released worker or a new task can change anything
+func (s *Scheduler) dispatch() time.Duration {
+	wait := time.Duration(0)
+	blocked := make(map[*queue]bool)
+
+	for s.budget.InUse() < s.budget.Size() {
+		q := s.next(blocked)
+		if q == nil {
+			return wait
+		}
+
+		i, release, taskWait := q.reserve(s.budget)
+		if i < 0 {
+			blocked[q] = true
+			if taskWait <= 0 {
+				taskWait = pollInterval
+			}
+			if wait == 0 || taskWait < wait {
+				wait = taskWait
+			}
+			continue
+		}
+
+		w := q.waiters[i]
+		q.waiters = slices.Delete(q.waiters, i, i+1)
+		if q.queued > 0 {
+			q.queued--
+			s.queued--
+		}
+		s.vtime = q.pass
+		q.pass += 1 / float64(q.job.Weight)
+
+		w.granted <- s.releaser(release)
+	}
+
+	return wait
+}
+
+// next returns the job with the lowest pass that has a task waiting
+func (s *Scheduler) next(blocked map[*queue]bool) *queue {
+	var best *queue
+	for _, q := range s.queues {
+		if blocked[q] || len(q.waiters) == 0 {
+			continue
+		}
+		if best == nil || q.pass < best.pass {
+			best = q
+		}
+	}
+	return best
+}
+
+// reserve finds the first waiting task the limiter lets through and takes
+// a slot of budget for it. Each key is only tried once so a throttled host
+// does not hold up the others
+func (q *queue) reserve(budget *worker.Budget) (int, func(), time.Duration) {
+	if q.job.Limiter == nil {
+		release, _, ok := budget.Reserve("")
+		if !ok {
+			return -1, nil, 0
+		}
+		return 0, release, 0
+	}
+	limiter := worker.Chain{budget, q.job.Limiter}
+
+	wait := time.Duration(0)
+	tried := make(map[string]bool)
+	for i, w := range q.waiters {
+		if tried[w.key] {
+			continue
+		}
+		tried[w.key] = true
+
+		release, keyWait, ok := limiter.Reserve(w.key)
+		if ok {
+			return i, release, 0
+		}
+		if keyWait > 0 && (wait == 0 || keyWait < wait) {
+			wait = keyWait
+		}
+	}
+	return -1, nil, wait
+}
+
+// releaser wraps release so the worker is given back once and the next
+// task is dispatched right away
+func (s *Scheduler) releaser(release func()) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			release()
+			s.signal()
+		})
+	}
+}
+
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,197 @@
+package scheduler_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func start(t *testing.T, cfg scheduler.Config) *scheduler.Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s := scheduler.New(cfg)
+	go s.Run(ctx)
+	return s
+}
+
+// runTasks runs one task per key of keys on workers goroutines, each
+// acquiring a worker of ticket for its task, and waits for all of them
+func runTasks(t *testing.T, ticket *scheduler.Ticket, workers int, keys []string, fn func(key string)) *sync.WaitGroup {
+	t.Helper()
+	next := make(chan string, len(keys))
+	for _, key := range keys {
+		next <- key
+	}
+	close(next)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range next {
+				release, err := ticket.Acquire(context.Background(), key)
+				if !assert.NoError(t, err) {
+					return
+				}
+				fn(key)
+				release()
+			}
+		}()
+	}
+	return &wg
+}
+
+func wait(t *testing.T, wg *sync.WaitGroup) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not finish")
+	}
+}
+
+func keys(key string, n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = key
+	}
+	return keys
+}
+
+func TestSchedulerSharesWorkersByWeight(t *testing.T) {
+	s := scheduler.New(scheduler.Config{Workers: 1})
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) {
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+
+	heavy, err := s.Submit(scheduler.Job{Weight: 3, Size: 30})
+	require.NoError(t, err)
+	light, err := s.Submit(scheduler.Job{Weight: 1, Size: 30})
+	require.NoError(t, err)
+	heavyDone := runTasks(t, heavy, 3, keys("heavy", 30), record)
+	lightDone := runTasks(t, light, 3, keys("light", 30), record)
+
+	// every task is waiting before the scheduler starts so neither job gets a head start
+	require.Eventually(t, func() bool { return s.Waiting() == 6 }, 5*time.Second, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	wait(t, heavyDone)
+	wait(t, lightDone)
+
+	counts := map[string]int{}
+	for _, name := range order[:20] {
+		counts[name]++
+	}
+	assert.Equal(t, 15, counts["heavy"])
+	assert.Equal(t, 5, counts["light"])
+}
+
+func TestSchedulerCapsConcurrency(t *testing.T) {
+	s := start(t, scheduler.Config{Workers: 4})
+
+	var active, peak atomic.Int32
+	task := func(string) {
+		if n := active.Add(1); n > peak.Load() {
+			peak.Store(n)
+		}
+		time.Sleep(5 * time.Millisecond)
+		active.Add(-1)
+	}
+
+	first, err := s.Submit(scheduler.Job{Size: 20})
+	require.NoError(t, err)
+	second, err := s.Submit(scheduler.Job{Size: 20})
+	require.NoError(t, err)
+	firstDone := runTasks(t, first, 8, keys("", 20), task)
+	secondDone := runTasks(t, second, 8, keys("", 20), task)
+	wait(t, firstDone)
+	wait(t, secondDone)
+
+	assert.LessOrEqual(t, peak.Load(), int32(4))
+	assert.Zero(t, s.Queued())
+}
+
+func TestSchedulerRespectsLimiter(t *testing.T) {
+	s := start(t, scheduler.Config{Workers: 4})
+	limiter := hostlimit.New(hostlimit.Limits{MaxConcurrent: 1})
+
+	var slowActive, slowPeak atomic.Int32
+	ticket, err := s.Submit(scheduler.Job{Limiter: limiter, Size: 5})
+	require.NoError(t, err)
+	done := runTasks(t, ticket, 5, []string{"slow", "slow", "slow", "fast", "fast"}, func(key string) {
+		if key == "slow" {
+			if n := slowActive.Add(1); n > slowPeak.Load() {
+				slowPeak.Store(n)
+			}
+			time.Sleep(10 * time.Millisecond)
+			slowActive.Add(-1)
+		}
+	})
+	wait(t, done)
+
+	assert.Equal(t, int32(1), slowPeak.Load())
+}
+
+func TestSchedulerRejectsJobsBeyondQueueLimit(t *testing.T) {
+	s := scheduler.New(scheduler.Config{Workers: 1, MaxQueued: 5})
+
+	first, err := s.Submit(scheduler.Job{Size: 3})
+	require.NoError(t, err)
+
+	_, err = s.Submit(scheduler.Job{Size: 3})
+	assert.ErrorIs(t, err, scheduler.ErrQueueFull)
+	assert.Equal(t, 3, s.Queued())
+
+	first.Done()
+	assert.Zero(t, s.Queued())
+	_, err = s.Submit(scheduler.Job{Size: 3})
+	assert.NoError(t, err)
+}
+
+func TestSchedulerStopsWaitingOnCancel(t *testing.T) {
+	s := start(t, scheduler.Config{Workers: 1})
+	ticket, err := s.Submit(scheduler.Job{Size: 3})
+	require.NoError(t, err)
+
+	release, err := ticket.Acquire(context.Background(), "")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	acquired := make(chan error)
+	go func() {
+		_, err := ticket.Acquire(ctx, "")
+		acquired <- err
+	}()
+	require.Eventually(t, func() bool { return s.Waiting() == 1 }, 5*time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-acquired, context.Canceled)
+	assert.Zero(t, s.Waiting())
+
+	// the worker held all along is still the only one, and it is given back
+	release()
+	release, err = ticket.Acquire(context.Background(), "")
+	require.NoError(t, err)
+	release()
+
+	ticket.Done()
+	assert.Zero(t, s.Queued())
+}
@@ -0,0 +1,96 @@
+// Package urlnorm parses submitted target urls strictly and brings them
+// into a canonical form so equal targets compare equal
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultScheme is what Normalize callers usually add to urls without a scheme
+const DefaultScheme = "https"
+
+var (
+	ErrMissingScheme = errors.New("missing scheme")
+	ErrMissingHost   = errors.New("missing host")
+	ErrInvalidHost   = errors.New("invalid host")
+)
+
+// Parse parses raw as an absolute url that must name a scheme and a host
+func Parse(raw string) (*url.URL, error) {
+	if raw == "" || strings.TrimSpace(raw) != raw {
+		return nil, fmt.Errorf("url must not be empty or padded with whitespace")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return nil, urlErr.Err
+		}
+		return nil, err
+	}
+
+	if u.Scheme == "" {
+		return nil, ErrMissingScheme
+	}
+	if u.Opaque != "" || u.Hostname() == "" {
+		return nil, ErrMissingHost
+	}
+	if _, err := asciiHost(u.Hostname()); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Normalize returns the canonical form of raw: a missing scheme becomes
+// defaultScheme, scheme and host are lowercased, internationalized host
+// names are converted to punycode and the fragment is dropped
+func Normalize(raw, defaultScheme string) (string, error) {
+	if !strings.Contains(raw, "://") && defaultScheme != "" {
+		raw = defaultScheme + "://" + raw
+	}
+
+	u, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	host, err := asciiHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = host
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String(), nil
+}
+
+// asciiHost lowercases host and converts it to its punycode form,
+// ip addresses are returned as they are
+func asciiHost(host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return strings.ToLower(host), nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidHost, err)
+	}
+	return ascii, nil
+}
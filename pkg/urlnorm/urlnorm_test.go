@@ -0,0 +1,53 @@
+package urlnorm_test
+
+import (
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/urlnorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	_, err := urlnorm.Parse("https://example.com/health")
+	assert.NoError(t, err)
+	_, err = urlnorm.Parse("tcp://[::1]:22")
+	assert.NoError(t, err)
+
+	_, err = urlnorm.Parse("example.com")
+	assert.ErrorIs(t, err, urlnorm.ErrMissingScheme)
+	_, err = urlnorm.Parse("mailto:someone@example.com")
+	assert.ErrorIs(t, err, urlnorm.ErrMissingHost)
+	_, err = urlnorm.Parse("https:///path")
+	assert.ErrorIs(t, err, urlnorm.ErrMissingHost)
+	_, err = urlnorm.Parse("https://exa mple.com")
+	assert.Error(t, err)
+	_, err = urlnorm.Parse("https://example.com:http")
+	assert.Error(t, err)
+	_, err = urlnorm.Parse(" https://example.com")
+	assert.Error(t, err)
+	_, err = urlnorm.Parse("ht!tp://x")
+	assert.Error(t, err)
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{raw: "example.com", expected: "https://example.com"},
+		{raw: "HTTP://Example.COM/Path?q=1#section", expected: "http://example.com/Path?q=1"},
+		{raw: "https://bücher.example/", expected: "https://xn--bcher-kva.example/"},
+		{raw: "https://EXAMPLE.com:8443", expected: "https://example.com:8443"},
+		{raw: "tcp://[::1]:22", expected: "tcp://[::1]:22"},
+		{raw: "dns://example.com?type=MX", expected: "dns://example.com?type=MX"},
+	}
+
+	for _, tt := range tests {
+		got, err := urlnorm.Normalize(tt.raw, "https")
+		assert.NoError(t, err, tt.raw)
+		assert.Equal(t, tt.expected, got, tt.raw)
+	}
+
+	_, err := urlnorm.Normalize("ht!tp://x", "https")
+	assert.Error(t, err)
+}
@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxLimitHistory bounds how many limit changes an Adaptive limiter keeps
+const maxLimitHistory = 1000
+
+// AdaptiveConfig tunes an Adaptive limiter. Limits start at Initial and move
+// between Min and Max, they grow by one for every window of successful jobs
+// and are multiplied by Backoff when a job fails or takes longer than
+// LatencyTolerance times the usual latency. Zero values take the defaults
+type AdaptiveConfig struct {
+	Initial          int
+	Min              int
+	Max              int
+	Backoff          float64
+	LatencyTolerance float64
+}
+
+func (c AdaptiveConfig) withDefaults() AdaptiveConfig {
+	if c.Min < 1 {
+		c.Min = 1
+	}
+	if c.Max < c.Min {
+		c.Max = max(c.Min, 100)
+	}
+	if c.Initial < c.Min || c.Initial > c.Max {
+		c.Initial = min(max(4, c.Min), c.Max)
+	}
+	if c.Backoff <= 0 || c.Backoff >= 1 {
+		c.Backoff = 0.5
+	}
+	if c.LatencyTolerance <= 1 {
+		c.LatencyTolerance = 2
+	}
+	return c
+}
+
+// LimitChange records a limit an Adaptive limiter moved to, Key is empty
+// for the limit shared by every key
+type LimitChange struct {
+	Key   string
+	Limit int
+	At    time.Duration
+}
+
+// aimd is one additive increase, multiplicative decrease limit
+type aimd struct {
+	limit    float64
+	inFlight int
+	// latency is the moving average of successful jobs
+	latency      time.Duration
+	lastDecrease time.Time
+}
+
+// observe moves the limit after a job finished and reports whether its
+// integer value changed
+func (l *aimd) observe(cfg AdaptiveConfig, now time.Time, latency time.Duration, failed bool) bool {
+	before := int(l.limit)
+
+	congested := failed || (l.latency > 0 && float64(latency) > float64(l.latency)*cfg.LatencyTolerance)
+	if congested {
+		// jobs started before the last decrease report the same congestion,
+		// only back off again once they had time to finish
+		if now.Sub(l.lastDecrease) > l.latency {
+			l.limit = math.Max(float64(cfg.Min), l.limit*cfg.Backoff)
+			l.lastDecrease = now
+		}
+	} else {
+		l.limit = math.Min(float64(cfg.Max), l.limit+1/l.limit)
+	}
+
+	if !failed {
+		if l.latency == 0 {
+			l.latency = latency
+		} else {
+			l.latency = (l.latency*4 + latency) / 5
+		}
+	}
+
+	return int(l.limit) != before
+}
+
+// Adaptive is a Limiter whose limits follow how targets respond. It keeps
+// one limit shared by every key and one per key, a job starts when both have
+// room. Callers report how each job went with Observe
+type Adaptive struct {
+	cfg     AdaptiveConfig
+	mu      sync.Mutex
+	shared  *aimd
+	keys    map[string]*aimd
+	start   time.Time
+	history []LimitChange
+	now     func() time.Time
+}
+
+// NewAdaptive creates an adaptive limiter
+func NewAdaptive(cfg AdaptiveConfig) *Adaptive {
+	cfg = cfg.withDefaults()
+	a := &Adaptive{
+		cfg:    cfg,
+		shared: &aimd{limit: float64(cfg.Initial)},
+		keys:   make(map[string]*aimd),
+		now:    time.Now,
+	}
+	a.start = a.now()
+	a.history = []LimitChange{{Limit: cfg.Initial}}
+	return a
+}
+
+// Reserve lets a job through when neither the shared limit nor the one of
+// key is used up
+func (a *Adaptive) Reserve(key string) (release func(), wait time.Duration, ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	k := a.key(key)
+	if a.shared.inFlight >= int(a.shared.limit) || k.inFlight >= int(k.limit) {
+		return nil, 0, false
+	}
+	a.shared.inFlight++
+	k.inFlight++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.mu.Lock()
+			a.shared.inFlight--
+			k.inFlight--
+			a.mu.Unlock()
+		})
+	}, 0, true
+}
+
+// Observe feeds the outcome of a job run under key back into the limits.
+// failed should only be set for failures that hint at an overloaded target
+func (a *Adaptive) Observe(key string, latency time.Duration, failed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if a.shared.observe(a.cfg, now, latency, failed) {
+		a.record("", a.shared, now)
+	}
+	if k := a.key(key); k.observe(a.cfg, now, latency, failed) {
+		a.record(key, k, now)
+	}
+}
+
+// Limit returns the current limit of key, or the shared one for an empty key
+func (a *Adaptive) Limit(key string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if key == "" {
+		return int(a.shared.limit)
+	}
+	return int(a.key(key).limit)
+}
+
+// History returns the limits the limiter went through, oldest first
+func (a *Adaptive) History() []LimitChange {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	history := make([]LimitChange, len(a.history))
+	copy(history, a.history)
+	return history
+}
+
+func (a *Adaptive) key(key string) *aimd {
+	k, ok := a.keys[key]
+	if !ok {
+		k = &aimd{limit: float64(a.cfg.Initial)}
+		a.keys[key] = k
+	}
+	return k
+}
+
+func (a *Adaptive) record(key string, l *aimd, now time.Time) {
+	if len(a.history) >= maxLimitHistory {
+		return
+	}
+	a.history = append(a.history, LimitChange{Key: key, Limit: int(l.limit), At: now.Sub(a.start)})
+}
@@ -0,0 +1,79 @@
+package worker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveGrowsWhileLatencyIsStable(t *testing.T) {
+	limiter := worker.NewAdaptive(worker.AdaptiveConfig{Initial: 2, Max: 5})
+
+	for i := 0; i < 50; i++ {
+		limiter.Observe("a.example", 20*time.Millisecond, false)
+	}
+
+	assert.Equal(t, 5, limiter.Limit(""))
+	assert.Equal(t, 5, limiter.Limit("a.example"))
+	assert.Equal(t, 2, limiter.Limit("b.example"), "other keys keep their own limit")
+
+	history := limiter.History()
+	assert.Equal(t, worker.LimitChange{Limit: 2}, history[0])
+	assert.Equal(t, 5, history[len(history)-1].Limit)
+}
+
+func TestAdaptiveBacksOffOnFailuresAndSlowdowns(t *testing.T) {
+	limiter := worker.NewAdaptive(worker.AdaptiveConfig{Initial: 8, Max: 8})
+	for i := 0; i < 10; i++ {
+		limiter.Observe("a.example", time.Second, false)
+	}
+
+	limiter.Observe("a.example", time.Second, true)
+	assert.Equal(t, 4, limiter.Limit("a.example"))
+
+	limiter.Observe("a.example", time.Second, true)
+	assert.Equal(t, 4, limiter.Limit("a.example"), "failures of jobs started before the back off are not counted twice")
+
+	limiter = worker.NewAdaptive(worker.AdaptiveConfig{Initial: 8, Max: 8})
+	limiter.Observe("b.example", time.Millisecond, false)
+	limiter.Observe("b.example", 50*time.Millisecond, false)
+	assert.Equal(t, 4, limiter.Limit("b.example"))
+}
+
+func TestAdaptiveCapsInFlightJobs(t *testing.T) {
+	limiter := worker.NewAdaptive(worker.AdaptiveConfig{Initial: 2, Max: 2})
+
+	release, _, ok := limiter.Reserve("a.example")
+	assert.True(t, ok)
+	_, _, ok = limiter.Reserve("a.example")
+	assert.True(t, ok)
+	_, _, ok = limiter.Reserve("a.example")
+	assert.False(t, ok)
+	_, _, ok = limiter.Reserve("b.example")
+	assert.False(t, ok, "the shared limit is used up as well")
+
+	release()
+	release()
+	_, _, ok = limiter.Reserve("b.example")
+	assert.True(t, ok)
+}
+
+func TestChainUndoesEarlierReservations(t *testing.T) {
+	adaptive := worker.NewAdaptive(worker.AdaptiveConfig{Initial: 1, Max: 1})
+	hosts := hostlimit.New(hostlimit.Limits{MaxConcurrent: 1})
+	chain := worker.Chain{adaptive, hosts}
+
+	hostRelease, _, ok := hosts.Reserve("a")
+	assert.True(t, ok)
+
+	_, _, ok = chain.Reserve("a")
+	assert.False(t, ok)
+
+	release, _, ok := chain.Reserve("b")
+	assert.True(t, ok, "the slot taken for a must have been given back")
+	release()
+	hostRelease()
+}
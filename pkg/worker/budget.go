@@ -0,0 +1,47 @@
+package worker
+
+import "time"
+
+// Budget caps how many jobs run at once across everything sharing it, it
+// ignores keys. A nil Budget never throttles
+type Budget struct {
+	slots chan struct{}
+}
+
+// NewBudget creates a budget of size slots, or nil for size 0 or less
+func NewBudget(size int) *Budget {
+	if size <= 0 {
+		return nil
+	}
+	return &Budget{slots: make(chan struct{}, size)}
+}
+
+// Reserve takes a slot when one is free
+func (b *Budget) Reserve(string) (release func(), wait time.Duration, ok bool) {
+	if b == nil {
+		return func() {}, 0, true
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return func() { <-b.slots }, 0, true
+	default:
+		return nil, 0, false
+	}
+}
+
+// Size returns the number of slots, 0 for an unlimited budget
+func (b *Budget) Size() int {
+	if b == nil {
+		return 0
+	}
+	return cap(b.slots)
+}
+
+// InUse returns how many slots are taken
+func (b *Budget) InUse() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.slots)
+}
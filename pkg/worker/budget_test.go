@@ -0,0 +1,49 @@
+package worker_test
+
+import (
+	"testing"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/hostlimit"
+	"github.com/alirezazahiri/gofetch-v2/pkg/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgetIgnoresKeys(t *testing.T) {
+	budget := worker.NewBudget(2)
+
+	first, _, ok := budget.Reserve("a.example")
+	assert.True(t, ok)
+	_, _, ok = budget.Reserve("b.example")
+	assert.True(t, ok)
+	_, _, ok = budget.Reserve("c.example")
+	assert.False(t, ok)
+	assert.Equal(t, 2, budget.InUse())
+
+	first()
+	_, _, ok = budget.Reserve("c.example")
+	assert.True(t, ok)
+}
+
+func TestBudgetIsSharedThroughChains(t *testing.T) {
+	budget := worker.NewBudget(1)
+	hosts := hostlimit.New(hostlimit.Limits{MaxConcurrent: 1})
+
+	release, _, ok := worker.Chain{budget}.Reserve("a")
+	assert.True(t, ok)
+	_, _, ok = worker.Chain{budget, hosts}.Reserve("b")
+	assert.False(t, ok)
+	release()
+
+	_, _, ok = worker.Chain{budget, hosts}.Reserve("b")
+	assert.True(t, ok)
+}
+
+func TestNilBudgetNeverThrottles(t *testing.T) {
+	var unlimited *worker.Budget
+	assert.Nil(t, worker.NewBudget(0))
+
+	_, _, ok := unlimited.Reserve("a")
+	assert.True(t, ok)
+	assert.Zero(t, unlimited.Size())
+	assert.Zero(t, unlimited.InUse())
+}
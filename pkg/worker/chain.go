@@ -0,0 +1,29 @@
+package worker
+
+import "time"
+
+// Chain lets a job through only when every limiter does. Earlier
+// reservations are undone by releasing them when a later limiter refuses,
+// so limiters that cannot be undone that way, such as rate limiters, go last
+type Chain []Limiter
+
+// Reserve behaves like Limiter.Reserve across the chain
+func (c Chain) Reserve(key string) (release func(), wait time.Duration, ok bool) {
+	releases := make([]func(), 0, len(c))
+	releaseAll := func() {
+		for _, release := range releases {
+			release()
+		}
+	}
+
+	for _, limiter := range c {
+		release, wait, ok := limiter.Reserve(key)
+		if !ok {
+			releaseAll()
+			return nil, wait, false
+		}
+		releases = append(releases, release)
+	}
+
+	return releaseAll, 0, true
+}
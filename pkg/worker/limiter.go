@@ -0,0 +1,10 @@
+package worker
+
+import "time"
+
+// Limiter decides whether a job with the given key may start now.
+// When ok is false, wait is how long until the key may be tried again,
+// 0 meaning once a running job releases its slot
+type Limiter interface {
+	Reserve(key string) (release func(), wait time.Duration, ok bool)
+}
@@ -0,0 +1,185 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"runtime/debug"
+	"slices"
+	"sync"
+)
+
+// Task processes one job. It should return early once ctx is done
+type Task[T any, R any] func(ctx context.Context, job T) (R, error)
+
+// Result is the outcome of a task, Index is the position of its job in the
+// input, counting from 0
+type Result[R any] struct {
+	Index int
+	Value R
+	Err   error
+}
+
+// PanicError is the error of a task that panicked
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task panicked: %v", e.Value)
+}
+
+// Options configures RunContext. Workers below 1 run a single worker and
+// Ordered delivers results in the order of their jobs instead of as they finish.
+// Buffer caps how many jobs are taken from the input before their results are
+// delivered, it defaults to twice the workers and is never below them
+type Options struct {
+	Workers int
+	Ordered bool
+	Buffer  int
+}
+
+func (o Options) withDefaults() Options {
+	o.Workers = max(o.Workers, 1)
+	if o.Buffer <= 0 {
+		o.Buffer = 2 * o.Workers
+	}
+	o.Buffer = max(o.Buffer, o.Workers)
+	return o
+}
+
+type indexedJob[T any] struct {
+	index int
+	job   T
+}
+
+// RunContext runs task over jobs on a pool of workers. A panicking task is
+// reported as a PanicError instead of taking the process down. The returned
+// channel is always closed, once every job ran or as soon as ctx is done,
+// in which case jobs not started yet are skipped and results not delivered
+// yet may be dropped
+func RunContext[T any, R any](ctx context.Context, jobs []T, opts Options, task Task[T, R]) <-chan Result[R] {
+	return RunSeq(ctx, slices.Values(jobs), opts, task)
+}
+
+// RunChan works like RunContext but takes jobs from a channel until it is
+// closed. Jobs are only received while the pool has room for them
+func RunChan[T any, R any](ctx context.Context, jobs <-chan T, opts Options, task Task[T, R]) <-chan Result[R] {
+	return RunSeq(ctx, func(yield func(T) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case job, ok := <-jobs:
+				if !ok || !yield(job) {
+					return
+				}
+			}
+		}
+	}, opts, task)
+}
+
+// RunSeq works like RunContext but pulls jobs from an iterator, so the input
+// never has to be held in memory at once. Once Options.Buffer jobs wait for
+// their results to be delivered the iterator is paused until one is
+func RunSeq[T any, R any](ctx context.Context, jobs iter.Seq[T], opts Options, task Task[T, R]) <-chan Result[R] {
+	opts = opts.withDefaults()
+	in := make(chan indexedJob[T])
+	results := make(chan Result[R])
+	out := make(chan Result[R])
+	// window holds a token for every job taken from the input whose result
+	// has not been delivered yet
+	window := make(chan struct{}, opts.Buffer)
+	var wg sync.WaitGroup
+
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range in {
+				value, err := runTask(ctx, task, j.job)
+				select {
+				case <-ctx.Done():
+					return
+				case results <- Result[R]{Index: j.index, Value: value, Err: err}:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(in)
+		i := 0
+		for job := range jobs {
+			select {
+			case <-ctx.Done():
+				return
+			case window <- struct{}{}:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case in <- indexedJob[T]{index: i, job: job}:
+			}
+			i++
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	go func() {
+		defer close(out)
+
+		// pending holds results that finished ahead of an earlier job
+		pending := make(map[int]Result[R])
+		next := 0
+		for result := range results {
+			if !opts.Ordered {
+				if !send(ctx, out, result) {
+					return
+				}
+				<-window
+				continue
+			}
+
+			pending[result.Index] = result
+			for {
+				result, ok := pending[next]
+				if !ok {
+					break
+				}
+				if !send(ctx, out, result) {
+					return
+				}
+				delete(pending, next)
+				<-window
+				next++
+			}
+		}
+	}()
+
+	return out
+}
+
+func runTask[T any, R any](ctx context.Context, task Task[T, R], job T) (value R, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = &PanicError{Value: recovered, Stack: debug.Stack()}
+		}
+	}()
+	return task(ctx, job)
+}
+
+// send delivers value unless ctx is done first
+func send[R any](ctx context.Context, out chan<- R, value R) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- value:
+		return true
+	}
+}
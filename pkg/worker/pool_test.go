@@ -0,0 +1,152 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alirezazahiri/gofetch-v2/pkg/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect[R any](t *testing.T, results <-chan R) []R {
+	var got []R
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				return got
+			}
+			got = append(got, result)
+		case <-timeout:
+			t.Fatal("results channel was not closed")
+		}
+	}
+}
+
+func TestRunContextKeepsOrderWhenAsked(t *testing.T) {
+	jobs := []int{30, 10, 20, 0}
+	results := worker.RunContext(context.Background(), jobs, worker.Options{Workers: 4, Ordered: true}, func(ctx context.Context, delay int) (int, error) {
+		time.Sleep(time.Duration(delay) * time.Millisecond)
+		return delay * 2, nil
+	})
+
+	got := collect(t, results)
+
+	require.Len(t, got, 4)
+	for i, result := range got {
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, jobs[i]*2, result.Value)
+		assert.NoError(t, result.Err)
+	}
+}
+
+func TestRunContextRecoversPanics(t *testing.T) {
+	failure := errors.New("failed")
+	results := worker.RunContext(context.Background(), []int{0, 1, 2}, worker.Options{Workers: 2, Ordered: true}, func(ctx context.Context, job int) (string, error) {
+		switch job {
+		case 1:
+			panic("boom")
+		case 2:
+			return "", failure
+		}
+		return "ok", nil
+	})
+
+	got := collect(t, results)
+
+	require.Len(t, got, 3)
+	assert.Equal(t, "ok", got[0].Value)
+
+	var panicErr *worker.PanicError
+	require.ErrorAs(t, got[1].Err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+
+	assert.ErrorIs(t, got[2].Err, failure)
+}
+
+func TestRunContextClosesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	jobs := make([]int, 100)
+
+	results := worker.RunContext(ctx, jobs, worker.Options{Workers: 2, Ordered: true}, func(ctx context.Context, job int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	cancel()
+
+	assert.Less(t, len(collect(t, results)), len(jobs))
+}
+
+func TestRunClosesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	jobs := make([]int, 100)
+
+	results := worker.Run(ctx, jobs, 2, func(job int) int {
+		time.Sleep(time.Millisecond)
+		return job
+	})
+	<-results
+	cancel()
+
+	assert.Less(t, len(collect(t, results)), len(jobs))
+}
+
+func TestRunSeqAppliesBackpressure(t *testing.T) {
+	var pulled atomic.Int32
+	pulls := make(chan struct{}, 100)
+	jobs := func(yield func(int) bool) {
+		for i := 0; i < 100; i++ {
+			pulled.Add(1)
+			pulls <- struct{}{}
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	results := worker.RunSeq(context.Background(), jobs, worker.Options{Workers: 2, Buffer: 4}, func(ctx context.Context, job int) (int, error) {
+		return job, nil
+	})
+
+	// the workers got their jobs, nobody read a result yet
+	for i := 0; i < 2; i++ {
+		<-pulls
+	}
+	// the pool never holds more than its buffer and the job waiting for room
+	// in it, so every result read lets at most one more job in
+	for read := int32(0); read < 100; read++ {
+		assert.LessOrEqual(t, pulled.Load(), 5+read)
+		_, ok := <-results
+		require.True(t, ok)
+	}
+
+	_, ok := <-results
+	assert.False(t, ok)
+}
+
+func TestRunChanConsumesUntilClosed(t *testing.T) {
+	jobs := make(chan string)
+	go func() {
+		defer close(jobs)
+		for _, job := range []string{"a", "b", "c"} {
+			jobs <- job
+		}
+	}()
+
+	results := worker.RunChan(context.Background(), jobs, worker.Options{Workers: 2, Ordered: true}, func(ctx context.Context, job string) (string, error) {
+		return strings.ToUpper(job), nil
+	})
+
+	var got []string
+	for _, result := range collect(t, results) {
+		got = append(got, result.Value)
+	}
+	assert.Equal(t, []string{"A", "B", "C"}, got)
+}
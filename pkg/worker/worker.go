@@ -6,7 +6,8 @@ import (
 	"sync"
 )
 
-// worker processes jobs from the jobs channel and sends results to the results channel
+// worker processes jobs from the jobs channel and sends results to the results channel.
+// It stops once ctx is done, dropping the result it was about to send
 func worker[T any, R any](ctx context.Context, wg *sync.WaitGroup, jobs <-chan T, results chan<- R, processFn func(T) R) {
 	defer wg.Done()
 
@@ -18,15 +19,17 @@ func worker[T any, R any](ctx context.Context, wg *sync.WaitGroup, jobs <-chan T
 			if !ok {
 				return
 			}
-			result := processFn(job)
-			results <- result
+			if !send(ctx, results, processFn(job)) {
+				return
+			}
 		}
 	}
 }
 
 // Run creates a worker pool that processes jobs concurrently
 // T is the type of input jobs, R is the type of results
-// It returns a channel that will receive all results
+// It returns a channel that will receive all results, closed once every job
+// ran or ctx is done. Use RunContext for tasks that take a context or may fail
 func Run[T any, R any](ctx context.Context, jobs []T, workers int, processFn func(T) R) <-chan R {
 	in := make(chan T)
 	out := make(chan R)
@@ -40,18 +43,20 @@ func Run[T any, R any](ctx context.Context, jobs []T, workers int, processFn fun
 
 	// Feed jobs and close channels when done
 	go func() {
+		defer func() {
+			close(in)
+			wg.Wait()
+			close(out)
+		}()
+
 		for _, job := range jobs {
 			select {
 			case <-ctx.Done():
-				close(in)
 				return
 			case in <- job:
 				log.Printf("JOB_SENT: job=%v", job)
 			}
 		}
-		close(in)
-		wg.Wait()
-		close(out)
 	}()
 
 	return out